@@ -12,6 +12,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/yijianlingcheng/go-exiftool"
 
+	"WaterMark/engine"
 	"WaterMark/engine/frame"
 	"WaterMark/internal"
 	"WaterMark/layout"
@@ -47,6 +48,7 @@ func checkCreateExportTask(ctx *gin.Context) pkg.EError {
 // @Param file formData string true "照片路径;多个文件,分割"
 // @Param layout formData string true "布局信息,JSON字符串:必须包含frame_name字段"
 // @Param preview_layout formData string true "布局信息,边框预览时调整保存的参数"
+// @Param export formData string false "导出选项,JSON字符串:format,jpeg_quality,png_compression,tiff_compression,metadata"
 // @Router /frame/createExportTask [post]
 // @Success 200 {object} NoError "成功信息".
 // @Failure 400 {object} ErrorInfo "错误信息".
@@ -67,6 +69,12 @@ func CreateExportTask(ctx *gin.Context) {
 
 		return
 	}
+	exportOpt, exportErr := layout.BuildExportOption(ctx.PostForm(paramQueryExport))
+	if pkg.HasError(exportErr) {
+		ctx.JSON(400, exportErr)
+
+		return
+	}
 	previewLayoutParams := ctx.PostForm(paramQueryPrevireLayout)
 	var previewLayoutMap map[string]string
 	err := json.Unmarshal([]byte(previewLayoutParams), &previewLayoutMap)
@@ -76,7 +84,7 @@ func CreateExportTask(ctx *gin.Context) {
 		return
 	}
 	// 开异步执行
-	go exportFrame(save, file, &layoutTpl, previewLayoutMap)
+	go exportFrame(save, file, &layoutTpl, previewLayoutMap, &exportOpt)
 
 	ctx.JSON(200, NoError{
 		Code:   0,
@@ -119,15 +127,21 @@ loop:
 }
 
 // 导出执行函数.
-func exportFrame(save, file string, layoutTpl *layout.FrameLayout, previewLayoutMap map[string]string) {
+func exportFrame(
+	save, file string,
+	layoutTpl *layout.FrameLayout,
+	previewLayoutMap map[string]string,
+	exportOpt *layout.ExportOption,
+) {
 	prex := time.Now().Format("2006-01-02-15_04_05")
 	workNum := 6
-	// 模糊模板需要限制为单线程处理
-	if layoutTpl.Isblur {
+	// 使用ImageMagick合成的模糊模板需要限制为单线程处理
+	if layoutTpl.Isblur && internal.IsMagickBlurCompositor() {
 		workNum = 1
 	}
 	task := make(chan struct{}, workNum)
 	var wg sync.WaitGroup
+	var mtx sync.Mutex
 	errors := make(map[string]pkg.EError, 0)
 	for file := range strings.SplitSeq(file, ",") {
 		task <- struct{}{}
@@ -138,7 +152,9 @@ func exportFrame(save, file string, layoutTpl *layout.FrameLayout, previewLayout
 
 			exifInfo, tpl, checkErr := checkExportFrameTask(path, previewLayoutMap, layoutTpl)
 			if pkg.HasError(checkErr) {
-				errors[file] = checkErr
+				mtx.Lock()
+				errors[path] = checkErr
+				mtx.Unlock()
 
 				sendExportProgress(path)
 				<-task
@@ -146,7 +162,12 @@ func exportFrame(save, file string, layoutTpl *layout.FrameLayout, previewLayout
 				return
 			}
 
-			exportFrameTask(save, path, prex, exifInfo, tpl)
+			exportErr := exportFrameTask(save, path, prex, exifInfo, tpl, exportOpt)
+			if pkg.HasError(exportErr) {
+				mtx.Lock()
+				errors[path] = exportErr
+				mtx.Unlock()
+			}
 
 			time.Sleep(100 * time.Microsecond)
 
@@ -210,18 +231,32 @@ func checkExportFrameTask(
 }
 
 // 执行导出.
-func exportFrameTask(save, path, prex string, exifInfo exiftool.FileMetadata, tpl *layout.FrameLayout) {
+func exportFrameTask(
+	save, path, prex string,
+	exifInfo exiftool.FileMetadata,
+	tpl *layout.FrameLayout,
+	exportOpt *layout.ExportOption,
+) pkg.EError {
+	saveImageFile := save + "/" + prex + "_" + exportOpt.GetSaveFileName(filepath.Base(path))
 	plug := frame.GetPlugin()
-	plug.CreateFrameImageRGBA(
+	img, frameErr := plug.CreateFrameImageRGBA(
 		map[string]any{
 			"sourceImageFile": path,
 			"photoType":       "photo",
 			"exif":            exifInfo,
 			"params":          tpl,
-			"saveImageFile":   save + "/" + prex + "_" + filepath.Base(path),
+			"saveImageFile":   saveImageFile,
 			"isBlur":          tpl.Isblur,
+			"export":          exportOpt,
 		},
 	)
+	if pkg.HasError(frameErr) {
+		return frameErr
+	}
+	// 拷贝原照片的元数据
+	bounds := img.Bounds()
+
+	return engine.CopyImageMetadata(path, saveImageFile, exportOpt, bounds.Dx(), bounds.Dy())
 }
 
 // 发送导出进度.
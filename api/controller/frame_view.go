@@ -1,7 +1,6 @@
 package controller
 
 import (
-	"image/jpeg"
 	"image/png"
 	"strconv"
 	"strings"
@@ -98,11 +97,13 @@ func ShowPhotoFrame(ctx *gin.Context) {
 		return
 	}
 	// 生成更小的图片,加快前端访问,将jpg图片作为输出直接返回
+	// 嵌入工作色彩空间的ICC配置文件,保证预览颜色与导出一致
 	var err error
+	profile := internal.GetWorkingColorProfile().Data
 	if layout.Isblur {
-		err = png.Encode(ctx.Writer, photoFrameResize(imageRGBA))
+		err = pkg.EncodePngWithColorProfile(ctx.Writer, photoFrameResize(imageRGBA), png.DefaultCompression, profile)
 	} else {
-		err = jpeg.Encode(ctx.Writer, photoFrameResize(imageRGBA), &jpeg.Options{Quality: 75})
+		err = pkg.EncodeJpegWithColorProfile(ctx.Writer, photoFrameResize(imageRGBA), 75, profile)
 	}
 	if err != nil {
 		message.SendErrorMsg("ShowPhotoFrame 接口出现错误:" + err.Error())
@@ -145,6 +146,71 @@ func ReloadFrameTemplate(ctx *gin.Context) {
 	ctx.JSON(200, pkg.NoError)
 }
 
+// @Summary 获取照片的相机与镜头展示名称
+// @Description 获取照片的相机与镜头名称按照名称映射文件转换前后的值,用于检查名称映射规则
+// @Tags Frame
+// @Produce json
+// @Param file formData string true "照片路径"
+// @Router /frame/getDisplayNameInfo [post]
+// @Success 200 {object} DisplayNameInfo "成功信息"
+// @Failure 400 {object} ErrorInfo "错误信息".
+func GetDisplayNameInfo(ctx *gin.Context) {
+	file := ctx.PostForm(paramQueryFile)
+	if file == "" {
+		ctx.JSON(400, requestParamError(paramFileIsEmpty))
+
+		return
+	}
+	if !internal.PathExists(file) {
+		ctx.JSON(400, requestResoureNotExistError(file, paramFileIsNotExist))
+
+		return
+	}
+	exifInfo, err := engine.CacheGetImageExif(file)
+	if pkg.HasError(err) {
+		ctx.JSON(400, err)
+
+		return
+	}
+	ctx.JSON(200, DisplayNameInfo{
+		Code: pkg.NO_ERROR,
+		List: layout.GetDisplayNames(exifInfo),
+	})
+}
+
+// @Summary 获取照片使用的logo以及匹配过程
+// @Description 按照logo匹配规则获取照片使用的相机logo与镜头logo,并返回每一条规则匹配或者被拒绝的原因
+// @Tags Frame
+// @Produce json
+// @Param file formData string true "照片路径"
+// @Router /frame/getLogoResolveInfo [post]
+// @Success 200 {object} LogoResolveInfo "成功信息"
+// @Failure 400 {object} ErrorInfo "错误信息".
+func GetLogoResolveInfo(ctx *gin.Context) {
+	file := ctx.PostForm(paramQueryFile)
+	if file == "" {
+		ctx.JSON(400, requestParamError(paramFileIsEmpty))
+
+		return
+	}
+	if !internal.PathExists(file) {
+		ctx.JSON(400, requestResoureNotExistError(file, paramFileIsNotExist))
+
+		return
+	}
+	exifInfo, err := engine.CacheGetImageExif(file)
+	if pkg.HasError(err) {
+		ctx.JSON(400, err)
+
+		return
+	}
+	ctx.JSON(200, LogoResolveInfo{
+		Code:       pkg.NO_ERROR,
+		Result:     layout.ResolveLogo(exifInfo),
+		LensResult: layout.ResolveLensLogo(exifInfo),
+	})
+}
+
 // @Summary 获取边框模板信息
 // @Description 获取边框模板信息,此接口用于展示边框模板列表
 // @Tags Frame
@@ -187,6 +253,11 @@ func ImportPhotoFiles(ctx *gin.Context) {
 		if !internal.PathExists(path) {
 			ctx.JSON(400, requestResoureNotExistError(path, paramFileIsNotExist))
 
+			return
+		}
+		if !pkg.IsSupportImageFile(path) {
+			ctx.JSON(400, requestParamError(path+":"+paramFileIsNotSupport))
+
 			return
 		}
 	}
@@ -260,10 +331,18 @@ func GetPhotoExifAndBorderInfo(ctx *gin.Context) {
 	}
 	size, sizeOk := info["size"].(map[string]int)
 	text, textOk := info["text"].([]string)
-	if !sizeOk || !textOk {
+	boxes, boxesOk := info["boxes"].(map[string]map[string]int)
+	lines, linesOk := info["lines"].([]map[string]any)
+	if !sizeOk || !textOk || !boxesOk || !linesOk {
 		ctx.JSON(400, pkg.InternalError)
 
 		return
 	}
-	ctx.JSON(200, ExifAndBorderInfo{Exif: exifInfoTranslatorApi(exifInfo), Size: frame.NewPhotoSize(size), Text: text})
+	ctx.JSON(200, ExifAndBorderInfo{
+		Exif:  exifInfoTranslatorApi(exifInfo),
+		Size:  frame.NewPhotoSize(size),
+		Text:  text,
+		Boxes: frame.NewBorderBoxes(boxes),
+		Lines: frame.NewTextLineBoxes(lines),
+	})
 }
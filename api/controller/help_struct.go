@@ -1,6 +1,10 @@
 package controller
 
-import "WaterMark/engine/frame"
+import (
+	"WaterMark/engine/frame"
+	"WaterMark/internal"
+	"WaterMark/layout"
+)
 
 type (
 	ErrorInfo struct {
@@ -54,11 +58,32 @@ type (
 	}
 
 	ExifAndBorderInfo struct {
-		Errmsg string          `json:"errmsg"`
-		Exif   ExifInfoSuccess `json:"exif"`
-		Text   []string        `json:"text"`
-		Size   frame.PhotoSize `json:"size"`
-		Code   int             `json:"code"`
+		Boxes  map[string]frame.BorderBox `json:"boxes"`
+		Errmsg string                     `json:"errmsg"`
+		Exif   ExifInfoSuccess            `json:"exif"`
+		Text   []string                   `json:"text"`
+		Lines  []frame.TextLineBox        `json:"lines"`
+		Size   frame.PhotoSize            `json:"size"`
+		Code   int                        `json:"code"`
+	}
+
+	DisplayNameInfo struct {
+		Errmsg string               `json:"errmsg"`
+		List   []layout.DisplayName `json:"list"`
+		Code   int                  `json:"code"`
+	}
+
+	LogoResolveInfo struct {
+		Errmsg     string                `json:"errmsg"`
+		Result     layout.LogoResolution `json:"result"`
+		LensResult layout.LogoResolution `json:"lens_result"`
+		Code       int                   `json:"code"`
+	}
+
+	WatermarkVerifyInfo struct {
+		Errmsg string                          `json:"errmsg"`
+		Result internal.InvisibleWatermarkInfo `json:"result"`
+		Code   int                             `json:"code"`
 	}
 
 	Message struct {
@@ -9,11 +9,17 @@ var (
 	paramQueryLayout = "layout"
 	// 预览时修改的布局参数.
 	paramQueryPrevireLayout = "preview_layout"
+	// 导出选项.
+	paramQueryExport = "export"
+	// 隐形水印的所有者ID.
+	paramQueryOwner = "owner_id"
 
 	paramFileIsEmpty = "file参数为空"
 
 	paramFileIsNotExist = "file请求的文件不存在"
 
+	paramFileIsNotSupport = "不支持的图片格式"
+
 	paramLayoutIsEmpty = "layout参数为空"
 
 	paramSaveIsEmpty = "未选择保存的路径"
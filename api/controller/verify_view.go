@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"WaterMark/internal"
+	"WaterMark/pkg"
+)
+
+// @Summary 校验照片中的隐形水印
+// @Description 读取导出照片中的隐形水印,返回所有者ID的hash与导出时间,重新压缩,缩放,或者不缩放只裁剪之后仍然可以读取
+// @Tags Verify
+// @Produce json
+// @Param file formData string true "照片路径"
+// @Param owner_id formData string false "所有者ID,为空时使用配置文件中的watermark.owner-id"
+// @Router /verify/watermark [post]
+// @Success 200 {object} WatermarkVerifyInfo "成功信息"
+// @Failure 400 {object} ErrorInfo "错误信息".
+func VerifyWatermark(ctx *gin.Context) {
+	file := ctx.PostForm(paramQueryFile)
+	if file == "" {
+		ctx.JSON(400, requestParamError(paramFileIsEmpty))
+
+		return
+	}
+	if !internal.PathExists(file) {
+		ctx.JSON(400, requestResoureNotExistError(file, paramFileIsNotExist))
+
+		return
+	}
+	info, err := internal.VerifyInvisibleWatermark(file, ctx.PostForm(paramQueryOwner))
+	if pkg.HasError(err) {
+		ctx.JSON(400, err)
+
+		return
+	}
+	ctx.JSON(200, WatermarkVerifyInfo{
+		Code:   pkg.NO_ERROR,
+		Result: info,
+	})
+}
@@ -45,8 +45,17 @@ func loadRouters(router *gin.Engine) {
 	frame.POST("reloadLogoImages", controller.ReloadLogoImages)
 	// 重新加载边框模板文件
 	frame.POST("reloadFrameTemplate", controller.ReloadFrameTemplate)
+	// 获取相机与镜头映射前后的名称
+	frame.POST("getDisplayNameInfo", controller.GetDisplayNameInfo)
+	// 获取照片使用的logo以及匹配过程
+	frame.POST("getLogoResolveInfo", controller.GetLogoResolveInfo)
 	// 获取边框模板信息
 	frame.GET("getFrameTemplateInfo", controller.GetFrameTemplateInfo)
+
+	// 校验接口
+	verify := router.Group("verify")
+	// 校验照片中的隐形水印
+	verify.POST("watermark", controller.VerifyWatermark)
 }
 
 // 注册不需要记录日志的API接口
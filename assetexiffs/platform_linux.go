@@ -0,0 +1,5 @@
+package assetexiffs
+
+func RestoreAssets(dir, name string) error {
+	return nil
+}
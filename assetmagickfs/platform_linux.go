@@ -0,0 +1,5 @@
+package assetmagickfs
+
+func RestoreAssets(dir, name string) error {
+	return nil
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// 命令行支持的子命令.
+var commands = map[string]func(args []string) int{
+	"frame":  frameCommand,
+	"verify": verifyCommand,
+}
+
+// 判断启动参数是否为命令行模式.
+func IsCommand(args []string) bool {
+	if len(args) < 2 {
+		return false
+	}
+	_, ok := commands[args[1]]
+
+	return ok
+}
+
+// 执行命令行,返回进程退出码.
+func Run(args []string) int {
+	if len(args) == 0 {
+		usage(os.Stderr)
+
+		return exitCode(paramError("缺少子命令"))
+	}
+	command, ok := commands[args[0]]
+	if !ok {
+		usage(os.Stderr)
+
+		return exitCode(paramError(args[0] + ":不支持的子命令"))
+	}
+
+	return command(args[1:])
+}
+
+// 输出命令行帮助信息.
+func usage(w io.Writer) {
+	fmt.Fprintf(w, `Usage: watermark <command> [options] files...
+
+Commands:
+  frame   对指定照片批量生成边框,不启动UI与api服务
+  verify  校验照片中的隐形水印,没有水印或者所有者不一致时返回图片错误
+
+Exit codes:
+  0 全部成功
+  1 文件错误  2 exiftool错误  3 csv错误  4 图片错误
+  5 命令执行错误  6 布局错误  9 参数或内部错误
+`)
+}
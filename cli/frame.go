@@ -0,0 +1,293 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"WaterMark/engine"
+	"WaterMark/engine/frame"
+	"WaterMark/internal"
+	"WaterMark/layout"
+	"WaterMark/pkg"
+)
+
+// 单张照片的处理任务.
+type frameTask struct {
+	path string
+	save string
+	err  pkg.EError
+}
+
+// frame 子命令的参数.
+type frameArgs struct {
+	layoutName string
+	params     string
+	out        string
+	prefix     string
+	files      []string
+	export     layout.ExportOption
+	workers    int
+}
+
+// 解析frame子命令参数.
+func parseFrameArgs(args []string, output io.Writer) (*frameArgs, pkg.EError) {
+	fa := &frameArgs{}
+	fs := flag.NewFlagSet("frame", flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.StringVar(&fa.layoutName, "layout", "", "模板名称,对应layout.json中的frame_name")
+	fs.StringVar(&fa.params, "params", "", "覆盖模板参数的JSON字符串,可选")
+	fs.StringVar(&fa.out, "out", "", "导出图片存放的文件夹")
+	fs.StringVar(&fa.prefix, "prefix", "", "导出文件名前缀,可选")
+	fs.IntVar(&fa.workers, "workers", 6, "并发处理的数量,使用ImageMagick合成的模糊模板固定为1")
+	fs.StringVar(&fa.export.Format, "format", "", "导出格式:jpg,png,tiff,bmp,默认与原照片一致")
+	fs.IntVar(&fa.export.JpegQuality, "quality", layout.EXPORT_DEFAULT_JPEG_QUALITY, "jpg导出质量,1-100")
+	fs.StringVar(&fa.export.PngCompression, "png-compression", layout.PNG_COMPRESSION_DEFAULT,
+		"png压缩级别:default,none,speed,best")
+	fs.StringVar(&fa.export.TiffCompression, "tiff-compression", layout.TIFF_COMPRESSION_DEFLATE,
+		"tiff压缩方式:deflate,none")
+	fs.StringVar(&fa.export.Metadata, "metadata", layout.METADATA_ALL, "元数据保留方式:all,none,no_gps")
+	if err := fs.Parse(args); err != nil {
+		return nil, paramError("参数解析失败:" + err.Error())
+	}
+	if exportErr := fa.export.Normalize(); pkg.HasError(exportErr) {
+		return nil, exportErr
+	}
+	if fa.layoutName == "" {
+		return nil, paramError("--layout参数为空")
+	}
+	if fa.out == "" {
+		return nil, paramError("--out参数为空")
+	}
+	fa.files = expandFiles(fs.Args())
+	if len(fa.files) == 0 {
+		return nil, paramError("没有需要处理的照片")
+	}
+
+	return fa, pkg.NoError
+}
+
+// 展开照片路径,兼容shell没有展开通配符的情况.
+func expandFiles(args []string) []string {
+	files := make([]string, 0, len(args))
+	for _, arg := range args {
+		matches, err := filepath.Glob(arg)
+		if err != nil || len(matches) == 0 {
+			files = append(files, arg)
+
+			continue
+		}
+		files = append(files, matches...)
+	}
+
+	return files
+}
+
+// 构造模板参数的JSON字符串.
+func (fa *frameArgs) getLayoutStr() (string, pkg.EError) {
+	params := make(map[string]any)
+	if fa.params != "" {
+		if err := json.Unmarshal([]byte(fa.params), &params); err != nil {
+			return "", paramError("--params参数格式错误,json解析失败")
+		}
+	}
+	params["frame_name"] = fa.layoutName
+	layoutStr, err := json.Marshal(params)
+	if err != nil {
+		return "", paramError("--params参数格式错误")
+	}
+
+	return string(layoutStr), pkg.NoError
+}
+
+// 获取导出图片的保存路径.
+func (fa *frameArgs) getSaveImageFile(path string) string {
+	return filepath.Join(fa.out, fa.prefix+fa.export.GetSaveFileName(filepath.Base(path)))
+}
+
+// 计算全部照片的保存路径,重复的照片只处理一次,文件名相同时依次添加序号,保存路径与照片路径相同时不处理.
+func (fa *frameArgs) getFrameTasks() []frameTask {
+	inputs := make(map[string]bool, len(fa.files))
+	for _, file := range fa.files {
+		inputs[getPathKey(file)] = true
+	}
+	tasks := make([]frameTask, 0, len(fa.files))
+	done := make(map[string]bool, len(fa.files))
+	saves := make(map[string]bool, len(fa.files))
+	for _, file := range fa.files {
+		key := getPathKey(file)
+		if done[key] {
+			continue
+		}
+		done[key] = true
+		save := fa.getSaveImageFile(file)
+		name, ext := strings.TrimSuffix(save, filepath.Ext(save)), filepath.Ext(save)
+		for i := 2; saves[getPathKey(save)]; i++ {
+			save = fmt.Sprintf("%s_%d%s", name, i, ext)
+		}
+		task := frameTask{path: file, save: save}
+		if inputs[getPathKey(save)] {
+			task.err = paramError(save + ":导出图片会覆盖原照片,请修改--out或者--prefix参数")
+		}
+		saves[getPathKey(save)] = true
+		tasks = append(tasks, task)
+	}
+
+	return tasks
+}
+
+// 获取用于比较的文件路径,windows与macOS的文件名默认不区分大小写.
+func getPathKey(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	path = filepath.Clean(path)
+	if !internal.IsLinux() {
+		path = strings.ToLower(path)
+	}
+
+	return path
+}
+
+// frame 子命令:初始化配置与工具,不启动UI,对指定照片批量生成边框.
+func frameCommand(args []string) int {
+	result := newSummary()
+	fa, argsErr := parseFrameArgs(args, os.Stderr)
+	if !pkg.HasError(argsErr) {
+		argsErr = fa.createOutDir()
+	}
+	if pkg.HasError(argsErr) {
+		result.setError(argsErr)
+
+		return result.write(os.Stdout)
+	}
+
+	// 使用独立的缓存文件夹,退出时只清理本次运行产生的文件
+	internal.UseCommandCacheDir()
+	collector := newMessageCollector(os.Stderr)
+	collector.start()
+	defer engine.QuitAllTools()
+	defer internal.CleanDir()
+
+	initErr := initFrameTools(collector)
+	if pkg.HasError(initErr) {
+		result.setError(initErr)
+		result.Messages = collector.getErrors()
+
+		return result.write(os.Stdout)
+	}
+
+	tpl, tplErr := fa.buildLayout()
+	if pkg.HasError(tplErr) {
+		result.setError(tplErr)
+
+		return result.write(os.Stdout)
+	}
+	collector.start()
+	fa.runTasks(tpl, result)
+
+	collector.stop()
+	result.Messages = collector.getErrors()
+
+	return result.write(os.Stdout)
+}
+
+// 初始化配置,资源与工具,返回初始化过程中的第一个错误.
+func initFrameTools(collector *messageCollector) pkg.EError {
+	internal.InitAppConfigsAndRes()
+	engine.InitAllTools()
+	collector.stop()
+
+	return collector.firstError()
+}
+
+// 创建导出文件夹.
+func (fa *frameArgs) createOutDir() pkg.EError {
+	if err := os.MkdirAll(fa.out, os.ModePerm); err != nil {
+		return pkg.NewErrors(pkg.FILE_NOT_OPEN_ERROR, fa.out+":导出文件夹创建失败:"+err.Error())
+	}
+
+	return pkg.NoError
+}
+
+// 构造模板.
+func (fa *frameArgs) buildLayout() (*layout.FrameLayout, pkg.EError) {
+	layoutStr, err := fa.getLayoutStr()
+	if pkg.HasError(err) {
+		return nil, err
+	}
+	tpl, buildErr := layout.BuildFrameLayout(layoutStr)
+	if pkg.HasError(buildErr) {
+		return nil, buildErr
+	}
+
+	return &tpl, pkg.NoError
+}
+
+// 并发执行全部照片的边框生成任务.
+func (fa *frameArgs) runTasks(tpl *layout.FrameLayout, result *summary) {
+	workNum := max(fa.workers, 1)
+	// 使用ImageMagick合成的模糊模板需要限制为单线程处理
+	if tpl.Isblur && internal.IsMagickBlurCompositor() {
+		workNum = 1
+	}
+	task := make(chan struct{}, workNum)
+	var wg sync.WaitGroup
+	var mtx sync.Mutex
+	for _, ft := range fa.getFrameTasks() {
+		if pkg.HasError(ft.err) {
+			result.addResult(ft.path, ft.save, ft.err)
+
+			continue
+		}
+		task <- struct{}{}
+		wg.Add(1)
+		go func(path, save string) {
+			defer wg.Done()
+
+			err := createFrameImage(path, save, tpl, &fa.export)
+
+			mtx.Lock()
+			result.addResult(path, save, err)
+			mtx.Unlock()
+			<-task
+		}(ft.path, ft.save)
+	}
+	wg.Wait()
+}
+
+// 生成单张照片的边框并保存.
+func createFrameImage(path, save string, tpl *layout.FrameLayout, exportOpt *layout.ExportOption) pkg.EError {
+	if !internal.PathExists(path) {
+		return pkg.NewErrors(pkg.FILE_NOT_EXIST_ERROR, path+":文件不存在")
+	}
+	exifInfo, exifErr := engine.CacheGetImageExif(path)
+	if pkg.HasError(exifErr) {
+		return exifErr
+	}
+	exifMake := pkg.AnyToString(exifInfo.Fields["Make"])
+	if layout.CheckLogoIsUnSupport(layout.GetLogoNameByExif(exifInfo)) {
+		return pkg.NewErrors(pkg.IMAGE_LOGO_NOT_FIND_ERROR, exifMake+":不支持的logo,请检查是否配置logo图片")
+	}
+	img, frameErr := frame.GetPlugin().CreateFrameImageRGBA(map[string]any{
+		"sourceImageFile": path,
+		"photoType":       "photo",
+		"exif":            exifInfo,
+		"params":          tpl,
+		"saveImageFile":   save,
+		"isBlur":          tpl.Isblur,
+		"export":          exportOpt,
+	})
+	if pkg.HasError(frameErr) {
+		return frameErr
+	}
+	// 拷贝原照片的元数据
+	bounds := img.Bounds()
+
+	return engine.CopyImageMetadata(path, save, exportOpt, bounds.Dx(), bounds.Dy())
+}
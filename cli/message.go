@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"WaterMark/message"
+	"WaterMark/pkg"
+)
+
+// 消息收集器.命令行模式下没有UI消费消息管道,需要自行读取,防止管道写满阻塞.
+type messageCollector struct {
+	out    io.Writer
+	done   chan struct{}
+	errors []string
+	wg     sync.WaitGroup
+	mtx    sync.Mutex
+}
+
+// 返回一个消息收集器,info消息输出到out中.
+func newMessageCollector(out io.Writer) *messageCollector {
+	return &messageCollector{
+		out:    out,
+		errors: make([]string, 0),
+	}
+}
+
+// 后台持续读取消息管道.
+func (mc *messageCollector) start() {
+	mc.done = make(chan struct{})
+	mc.wg.Add(1)
+	go func() {
+		defer mc.wg.Done()
+		for {
+			select {
+			case <-mc.done:
+				return
+			default:
+				mc.drain()
+				time.Sleep(10 * time.Millisecond)
+			}
+		}
+	}()
+}
+
+// 停止后台读取,并读取管道中剩余的消息.
+func (mc *messageCollector) stop() {
+	if mc.done != nil {
+		close(mc.done)
+		mc.wg.Wait()
+		mc.done = nil
+	}
+	mc.drain()
+}
+
+// 读取管道中当前全部的消息.
+func (mc *messageCollector) drain() {
+	mc.mtx.Lock()
+	defer mc.mtx.Unlock()
+
+	for {
+		select {
+		case info, ok := <-message.Info_Messge_Chan:
+			if ok {
+				fmt.Fprintf(mc.out, "[info] %s\n", info)
+			}
+		case errStr, ok := <-message.Error_Messge_Chan:
+			if ok {
+				fmt.Fprintf(mc.out, "[error] %s\n", errStr)
+				mc.errors = append(mc.errors, errStr)
+			}
+		default:
+			return
+		}
+	}
+}
+
+// 获取收集到的全部错误消息.
+func (mc *messageCollector) getErrors() []string {
+	mc.mtx.Lock()
+	defer mc.mtx.Unlock()
+
+	return append([]string{}, mc.errors...)
+}
+
+// 获取收集到的第一个错误,错误消息是EError的JSON格式时还原错误码.
+func (mc *messageCollector) firstError() pkg.EError {
+	list := mc.getErrors()
+	if len(list) == 0 {
+		return pkg.NoError
+	}
+	var e struct {
+		Error string `json:"Error"`
+		Code  int    `json:"Code"`
+	}
+	if err := json.Unmarshal([]byte(list[0]), &e); err != nil || e.Code == 0 {
+		return pkg.NewErrors(pkg.INTERNAL_ERROR, list[0])
+	}
+
+	return pkg.NewErrors(e.Code, e.Error)
+}
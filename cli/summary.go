@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"encoding/json"
+	"io"
+
+	"WaterMark/internal"
+	"WaterMark/pkg"
+)
+
+type (
+	// 命令执行结果汇总,以JSON格式输出到标准输出.
+	summary struct {
+		Errmsg   string       `json:"errmsg"`
+		Files    []fileResult `json:"files"`
+		Messages []string     `json:"messages"`
+		Code     int          `json:"code"`
+		Total    int          `json:"total"`
+		Success  int          `json:"success"`
+		Failed   int          `json:"failed"`
+	}
+
+	// 单个文件的处理结果.
+	fileResult struct {
+		File   string `json:"file"`
+		Save   string `json:"save"`
+		Errmsg string `json:"errmsg"`
+		// 隐形水印的校验结果,只有verify子命令返回
+		Watermark *internal.InvisibleWatermarkInfo `json:"watermark,omitempty"`
+		Code      int                              `json:"code"`
+	}
+)
+
+// 错误码分类的基数,EError错误码的最高位代表错误的分类.
+const exitCodeBase = 1000000
+
+// 返回一个汇总结果.
+func newSummary() *summary {
+	return &summary{
+		Files:    make([]fileResult, 0),
+		Messages: make([]string, 0),
+	}
+}
+
+// 记录单个文件的处理结果.
+func (s *summary) addResult(file, save string, err pkg.EError) {
+	result := fileResult{File: file, Save: save}
+	s.Total++
+	if pkg.HasError(err) {
+		s.Failed++
+		result.Code = err.Code
+		result.Errmsg = err.Error.Error()
+		// 以第一个失败的文件作为整体的错误
+		if s.Code == pkg.NO_ERROR {
+			s.setError(err)
+		}
+	} else {
+		s.Success++
+	}
+	s.Files = append(s.Files, result)
+}
+
+// 记录单个文件的隐形水印校验结果.
+func (s *summary) addWatermarkResult(info internal.InvisibleWatermarkInfo, err pkg.EError) {
+	s.addResult(info.File, "", err)
+	s.Files[len(s.Files)-1].Watermark = &info
+}
+
+// 设置整体错误.
+func (s *summary) setError(err pkg.EError) {
+	s.Code = err.Code
+	s.Errmsg = err.Error.Error()
+}
+
+// 输出汇总结果并返回进程退出码.
+func (s *summary) write(w io.Writer) int {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(s); err != nil {
+		return exitCode(pkg.InternalError)
+	}
+
+	return exitCode(pkg.NewErrors(s.Code, s.Errmsg))
+}
+
+// 将EError错误码映射为进程退出码,取错误码的分类位(1~9).
+func exitCode(err pkg.EError) int {
+	if !pkg.HasError(err) {
+		return 0
+	}
+	code := err.Code / exitCodeBase
+	if code <= 0 || code > 9 {
+		return pkg.INTERNAL_ERROR / exitCodeBase
+	}
+
+	return code
+}
+
+// 参数错误.
+func paramError(msg string) pkg.EError {
+	return pkg.NewErrors(pkg.REQUEST_PARAM_ERROR, msg)
+}
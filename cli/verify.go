@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"flag"
+	"io"
+	"os"
+
+	"WaterMark/internal"
+	"WaterMark/pkg"
+)
+
+// verify 子命令的参数.
+type verifyArgs struct {
+	owner string
+	files []string
+}
+
+// 解析verify子命令参数.
+func parseVerifyArgs(args []string, output io.Writer) (*verifyArgs, pkg.EError) {
+	va := &verifyArgs{}
+	fs := flag.NewFlagSet("verify", flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.StringVar(&va.owner, "owner", "", "所有者ID,为空时使用配置文件中的watermark.owner-id")
+	if err := fs.Parse(args); err != nil {
+		return nil, paramError("参数解析失败:" + err.Error())
+	}
+	va.files = expandFiles(fs.Args())
+	if len(va.files) == 0 {
+		return nil, paramError("没有需要校验的照片")
+	}
+
+	return va, pkg.NoError
+}
+
+// verify 子命令:初始化配置,不启动UI,读取照片中的隐形水印并与所有者ID比较.
+func verifyCommand(args []string) int {
+	result := newSummary()
+	va, argsErr := parseVerifyArgs(args, os.Stderr)
+	if pkg.HasError(argsErr) {
+		result.setError(argsErr)
+
+		return result.write(os.Stdout)
+	}
+
+	// 使用独立的缓存文件夹,退出时只清理本次运行产生的文件
+	internal.UseCommandCacheDir()
+	collector := newMessageCollector(os.Stderr)
+	collector.start()
+	defer internal.CleanDir()
+
+	// 初始化配置,校验不依赖exiftool,初始化的错误只作为消息输出
+	internal.InitAppConfigsAndRes()
+	if va.owner == "" {
+		va.owner = internal.GetWatermarkOwnerID()
+	}
+	if va.owner == "" {
+		collector.stop()
+		result.setError(paramError("--owner参数为空,并且没有配置watermark.owner-id"))
+		result.Messages = collector.getErrors()
+
+		return result.write(os.Stdout)
+	}
+	for _, file := range va.files {
+		info, err := verifyWatermark(file, va.owner)
+		result.addWatermarkResult(info, err)
+	}
+
+	collector.stop()
+	result.Messages = collector.getErrors()
+
+	return result.write(os.Stdout)
+}
+
+// 校验单张照片,没有读取到水印或者所有者不一致时返回错误.
+func verifyWatermark(path, owner string) (internal.InvisibleWatermarkInfo, pkg.EError) {
+	if !internal.PathExists(path) {
+		return internal.InvisibleWatermarkInfo{File: path}, pkg.NewErrors(pkg.FILE_NOT_EXIST_ERROR, path+":文件不存在")
+	}
+	info, err := internal.VerifyInvisibleWatermark(path, owner)
+	if pkg.HasError(err) {
+		return info, err
+	}
+	if !info.Found {
+		return info, pkg.NewErrors(pkg.IMAGE_INVISIBLE_WATERMARK_ERROR, path+":没有找到隐形水印")
+	}
+	if !info.OwnerMatch {
+		return info, pkg.NewErrors(pkg.IMAGE_INVISIBLE_WATERMARK_ERROR, path+":隐形水印的所有者与所有者ID不一致")
+	}
+
+	return info, pkg.NoError
+}
@@ -0,0 +1,20 @@
+package exift
+
+import (
+	"github.com/yijianlingcheng/go-exiftool"
+
+	"WaterMark/internal"
+	"WaterMark/pkg"
+)
+
+// 初始化exiftool工具,使用PATH或者配置文件夹中查找到的exiftool.
+func InitExiftool() (*exiftool.Exiftool, pkg.EError) {
+	et, err := exiftool.NewExiftool(exiftool.SetExiftoolBinaryPath(internal.GetExiftoolPath()))
+	if err != nil {
+		internal.Log.Error("初始化exiftool工具失败: " + err.Error())
+
+		return nil, pkg.ExiftoolInitError
+	}
+
+	return et, pkg.NoError
+}
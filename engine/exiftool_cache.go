@@ -35,6 +35,13 @@ func CacheGetImageExif(path string) (exiftool.FileMetadata, pkg.EError) {
 
 		return exiftool.FileMetadata{}, pkg.NewErrors(pkg.ExiftoolImageError.Code, errmsg)
 	}
+	// RAW文件使用内嵌的预览图片生成边框
+	if pkg.IsRawImageFile(path) {
+		rawErr := setRawPreviewExif(path, exifInfos[0])
+		if pkg.HasError(rawErr) {
+			return exiftool.FileMetadata{}, rawErr
+		}
+	}
 	exiftoolCache.Store(md5, exifInfos[0])
 
 	exifData := copyExifData(exifInfos[0])
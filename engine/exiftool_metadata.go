@@ -0,0 +1,51 @@
+package engine
+
+import (
+	"strconv"
+	"time"
+
+	"WaterMark/internal"
+	"WaterMark/internal/cmd"
+	"WaterMark/layout"
+	"WaterMark/pkg"
+)
+
+// 将原照片的exif,xmp信息拷贝到导出的图片中,并按照导出图片的尺寸更新宽高与方向.
+// 常驻的exiftool实例只支持无序的标签赋值,-TagsFromFile需要保证参数顺序,所以单独调用exiftool执行.
+// 导出图片已经嵌入工作色彩空间的ICC配置文件,不拷贝原照片的ICC配置文件.
+func CopyImageMetadata(src, dst string, opt *layout.ExportOption, width, height int) pkg.EError {
+	if !opt.NeedCopyMetadata(dst) {
+		return pkg.NoError
+	}
+	_, err := cmd.CommandRunWithArgs(time.Minute, getCopyMetadataArgs(src, dst, opt.Metadata, width, height))
+	if pkg.HasError(err) {
+		internal.Log.Error(dst + ":写入元数据失败:" + err.String())
+
+		return pkg.NewErrors(pkg.EXIFTOOL_WRITE_METADATA_ERROR, dst+":写入元数据失败:"+err.Error.Error())
+	}
+
+	return pkg.NoError
+}
+
+// 获取拷贝元数据的exiftool参数.
+func getCopyMetadataArgs(src, dst, mode string, width, height int) []string {
+	args := []string{
+		internal.GetExiftoolPath(),
+		"-m", "-overwrite_original",
+		"-TagsFromFile", src, "-all:all", "--ICC_Profile:all",
+	}
+	if mode == layout.METADATA_NO_GPS {
+		args = append(args, "--GPS:all", "--XMP:GPS*")
+	}
+	w := strconv.Itoa(width)
+	h := strconv.Itoa(height)
+	// 导出图片已经按照方向旋转,方向重置为正常
+	args = append(args,
+		"-ImageWidth="+w, "-ImageHeight="+h,
+		"-ExifImageWidth="+w, "-ExifImageHeight="+h,
+		"-Orientation#=1",
+		dst,
+	)
+
+	return args
+}
@@ -2,9 +2,13 @@ package native
 
 import (
 	"image"
+	"image/color"
 	"image/draw"
 	"runtime"
+	"strconv"
+	"time"
 
+	"WaterMark/internal"
 	"WaterMark/layout"
 	"WaterMark/message"
 	"WaterMark/pkg"
@@ -18,6 +22,8 @@ type (
 		drawFrame()
 		getFrameSize() map[string]int
 		getBorderText() []string
+		getBorderBoxes() map[string]map[string]int
+		getTextLineBoxes() []map[string]any
 		getSaveImageFile() string
 		getLayoutName() string
 		getLayoutParams() *layout.FrameLayout
@@ -107,9 +113,8 @@ func (fm *basePhotoFrame) getFrameSize() map[string]int {
 	if fm.isBlur {
 		isBlur = 1
 	}
-	w := fm.opts.getSourceImageX()
-	h := fm.opts.getSourceImageY()
-	borderRadius := max(w, h) * fm.opts.Params.BorderRadius / 1000
+	borderRadius := fm.getBorderRadius()
+	shadow := fm.getPhotoShadow(borderRadius)
 
 	return map[string]int{
 		"borderLeftWidth":    fm.borImage.leftWidth,
@@ -120,7 +125,69 @@ func (fm *basePhotoFrame) getFrameSize() map[string]int {
 		"sourceHeight":       fm.srcImage.height,
 		"isBlur":             isBlur,
 		"borderRadius":       borderRadius,
+		"shadowBlur":         shadow.sigma,
+		"shadowOffsetX":      shadow.offsetX,
+		"shadowOffsetY":      shadow.offsetY,
+		"shadowOpacity":      shadow.opacity,
+		"shadowColor":        strColor2Int(fm.opts.Params.ShadowColor, BLUR_SHADOW_COLOR),
+		"strokeWidth":        fm.getPhotoStrokeWidth(),
+		"strokeColor":        strColor2Int(fm.opts.Params.StrokeColor, PHOTO_STROKE_COLOR),
+	}
+}
+
+// 获取照片圆角的像素大小,border_radius为照片长边的千分比.
+func (fm *basePhotoFrame) getBorderRadius() int {
+	w := fm.opts.getSourceImageX()
+	h := fm.opts.getSourceImageY()
+
+	return max(w, h) * fm.opts.Params.BorderRadius / 1000
+}
+
+// 获取照片阴影参数,阴影模糊与偏移为照片长边的千分比.
+func (fm *basePhotoFrame) getPhotoShadow(borderRadius int) roundedShadow {
+	params := fm.opts.Params
+	longEdge := max(fm.opts.getSourceImageX(), fm.opts.getSourceImageY())
+	sigma := longEdge * params.ShadowBlur / 1000
+	// 模糊边框默认与magick的阴影参数一致,普通边框默认没有阴影
+	if params.ShadowBlur <= 0 && fm.isBlur {
+		sigma = borderRadius
+	}
+	opacity := params.ShadowOpacity
+	if opacity == 0 {
+		opacity = BLUR_SHADOW_OPACITY
+	}
+	shadowColor := params.ShadowColor
+	if shadowColor == "" {
+		shadowColor = BLUR_SHADOW_COLOR
+	}
+
+	return roundedShadow{
+		color:   strColor2RGBA(shadowColor),
+		radius:  borderRadius,
+		sigma:   sigma,
+		offsetX: longEdge * params.ShadowOffsetX / 1000,
+		offsetY: longEdge * params.ShadowOffsetY / 1000,
+		opacity: opacity,
+	}
+}
+
+// 获取照片内描边的像素宽度,stroke_width为照片长边的千分比,配置之后至少为1像素.
+func (fm *basePhotoFrame) getPhotoStrokeWidth() int {
+	if fm.opts.Params.StrokeWidth <= 0 {
+		return 0
+	}
+	longEdge := max(fm.opts.getSourceImageX(), fm.opts.getSourceImageY())
+
+	return max(1, longEdge*fm.opts.Params.StrokeWidth/1000)
+}
+
+// 获取照片内描边的颜色.
+func (fm *basePhotoFrame) getPhotoStrokeColor() color.RGBA {
+	if fm.opts.Params.StrokeColor == "" {
+		return strColor2RGBA(PHOTO_STROKE_COLOR)
 	}
+
+	return strColor2RGBA(fm.opts.Params.StrokeColor)
 }
 
 // 获取边框上展示的文字信息.
@@ -131,12 +198,35 @@ func (fm *basePhotoFrame) getBorderText() []string {
 		if fm.borImage.textLay.list[i].words == "" {
 			continue
 		}
-		data = append(data, textWordsList[i], key, changeText2ExifContent(fm.opts.getExif(), key))
+		data = append(data, fm.getTextWordsName(i), key, changeText2ExifContent(fm.opts.getExif(), key))
+	}
+	for _, side := range sideBandList {
+		key := fm.getSideBand(side).Content
+		if key == "" {
+			continue
+		}
+		data = append(data, sideBandWordsList[side], key, changeText2ExifContent(fm.opts.getExif(), key))
+	}
+	for i := range fm.opts.Params.Overlays {
+		key := fm.opts.Params.Overlays[i].Content
+		if key == "" {
+			continue
+		}
+		data = append(data, getOverlayWordsName(i), key, changeText2ExifContent(fm.opts.getExif(), key))
 	}
 
 	return data
 }
 
+// 获取文字内容对应的字段名称,旧版本模板使用原有的字段名称.
+func (fm *basePhotoFrame) getTextWordsName(index int) string {
+	if fm.opts.isLegacyTexts && index < len(textWordsList) {
+		return textWordsList[index]
+	}
+
+	return "texts." + strconv.Itoa(index) + ".content"
+}
+
 // 初始化.
 func (fm *basePhotoFrame) initFrame(opts map[string]any) pkg.EError {
 	fm.opts = newFrameOption(opts)
@@ -165,6 +255,8 @@ func (fm *basePhotoFrame) initFrame(opts map[string]any) pkg.EError {
 	} else {
 		fm.srcImage.SetImageXAndY(fm.opts.getSourceImageX(), fm.opts.getSourceImageY())
 	}
+	// 根据照片计算自动的背景与分割线颜色
+	fm.setAutoColor()
 	// 初始化最终绘制对象
 	fm.finImage = newFinalImage(fm.opts)
 
@@ -214,7 +306,7 @@ func (fm *basePhotoFrame) resetSourceImageXAndY() {
 
 // 加载图片.
 func (fm *basePhotoFrame) loadSourceImage(path string) (image.Image, pkg.EError) {
-	image, loadErr := pkg.LoadImageWithDecode(path)
+	image, loadErr := internal.LoadImageWithWorkingColorSpace(path)
 	if pkg.HasError(loadErr) {
 		return nil, loadErr
 	}
@@ -234,6 +326,19 @@ func (fm *basePhotoFrame) getSaveImageFile() string {
 	return fm.opts.SaveImageFile
 }
 
+// 导出照片时嵌入包含所有者与导出时间的隐形水印,没有配置所有者ID或者只生成边框时不嵌入.
+func (fm *basePhotoFrame) embedInvisibleWatermark(img draw.Image, photoRect image.Rectangle) draw.Image {
+	owner := internal.GetWatermarkOwnerID()
+	if owner == "" || !fm.opts.needSourceImage() {
+		return img
+	}
+
+	return pkg.EmbedInvisibleWatermark(img, photoRect, pkg.InvisibleWatermark{
+		OwnerHash: pkg.InvisibleWatermarkOwnerHash(owner),
+		Timestamp: time.Now().Unix(),
+	})
+}
+
 // 清理.
 //
 //nolint:revive
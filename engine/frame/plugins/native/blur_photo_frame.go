@@ -41,8 +41,9 @@ func (fm *blurPhotoFrame) initFrame(opts map[string]any) pkg.EError {
 	}
 	fm.borImage = borImage
 
-	// 判断是否需要加载原图
-	if fm.opts.needSourceImage() && !checkBlurImageExist(fm.getBlurBackgroundImageFilePath()) {
+	// 判断是否需要加载原图,原生合成圆角阴影时始终需要原图
+	if fm.opts.needSourceImage() &&
+		(!internal.IsMagickBlurCompositor() || !checkBlurImageExist(fm.getBlurBackgroundImageFilePath())) {
 		sourceImage, loadSourceImageErr := fm.loadSourceImage(sourceImagePath)
 		if pkg.HasError(loadSourceImageErr) {
 			return loadSourceImageErr
@@ -82,7 +83,7 @@ func (fm *blurPhotoFrame) createBlurDraw() (*image.NRGBA, pkg.EError) {
 	blurBackgroundImagePath := fm.getBlurBackgroundImageFilePath()
 	// 判断是否已经存在模糊背景图片
 	if checkBlurImageExist(blurBackgroundImagePath) {
-		blurImage, loadErr := pkg.LoadImageWithDecode(blurBackgroundImagePath)
+		blurImage, loadErr := internal.LoadImageWithWorkingColorSpace(blurBackgroundImagePath)
 		if pkg.HasError(loadErr) {
 			return nil, loadErr
 		}
@@ -152,17 +153,20 @@ func (fm *blurPhotoFrame) getBlurSaveImageFile() string {
 	return fm.tmpBlurResultImagePath
 }
 
-// 绘制模糊模板主体. 绘制圆角阴影使用imagemagick工具实现.
+// 绘制模糊模板主体. 默认使用原生代码绘制圆角阴影,配置为magick时使用imagemagick工具实现.
 func (fm *blurPhotoFrame) drawBlurMainImage() {
 	if !fm.opts.needSourceImage() {
 		return
 	}
+	if !internal.IsMagickBlurCompositor() {
+		fm.drawBlurMainImageNative()
+
+		return
+	}
 	// 等待模糊图片生成完成
 	waitBlurImageInList(fm.getBlurBackgroundImageFilePath())
 
-	w := fm.opts.getSourceImageX()
-	h := fm.opts.getSourceImageY()
-	borderRadius := max(w, h) * fm.opts.Params.BorderRadius / 1000
+	borderRadius := fm.getBorderRadius()
 
 	_, imageErr := cmd.CommandRunWithArgs(5*time.Minute, fm.getMagickCmdArgs(borderRadius, fm.getBlurSaveImageFile()))
 	if pkg.HasError(imageErr) {
@@ -182,10 +186,38 @@ func (fm *blurPhotoFrame) drawBlurMainImage() {
 	fm.frameDraw = imaging.Clone(image)
 }
 
+// 使用原生代码绘制圆角阴影与照片主体,不依赖生成的模糊图片文件.
+func (fm *blurPhotoFrame) drawBlurMainImageNative() {
+	canvas := image.NewRGBA(fm.frameDraw.Bounds())
+	draw.Draw(canvas, canvas.Bounds(), fm.frameDraw, fm.frameDraw.Bounds().Min, draw.Src)
+
+	borderRadius := fm.getBorderRadius()
+	rect := fm.getPhotoRect()
+
+	drawRoundedShadow(canvas, rect, fm.getPhotoShadow(borderRadius))
+	drawRoundedImage(canvas, rect.Min, fm.srcImage.imgDecode, borderRadius)
+	drawRoundedStroke(canvas, rect, borderRadius, fm.getPhotoStrokeWidth(), fm.getPhotoStrokeColor())
+	fm.frameDraw = canvas
+}
+
+// 获取照片在最终图片中所在的区域,与magick合成时的位置保持一致,有圆角时照片水平居中.
+func (fm *blurPhotoFrame) getPhotoRect() image.Rectangle {
+	w := fm.opts.getSourceImageX()
+	h := fm.opts.getSourceImageY()
+	marginLeft := fm.opts.Params.MainMarginLeft
+	if fm.getBorderRadius() > 0 {
+		marginLeft = (fm.finImage.width - w) / 2
+	}
+
+	return image.Rect(0, 0, w, h).Add(image.Pt(marginLeft, fm.opts.Params.MainMarginTop))
+}
+
 // 画模糊边框与文字.
 func (fm *blurPhotoFrame) drawBlurBorderImage() pkg.EError {
 	// 生成边框对象
 	fm.borderDraw = loadImageRGBA(0, 0, fm.srcImage.width, fm.borImage.bottomHeight)
+	// 按照模糊背景的深浅调整文字颜色
+	fm.setTextContrastColor(fm.getBorderRects()[SIDE_BOTTOM])
 
 	simpleBorderFactory := &SimpleBorderFactory{}
 	simpleBorderFactory.createBorder(fm.opts.Params.Name).drawBorder(fm)
@@ -198,6 +230,8 @@ func (fm *blurPhotoFrame) drawBlurBorderImage() pkg.EError {
 func (fm *blurPhotoFrame) drawFrame() {
 	// 画主体
 	fm.drawBlurMainImage()
+	// 画照片上的水印
+	fm.drawOverlays(fm.frameDraw, fm.getPhotoRect())
 	// 画边框
 	fm.drawBlurBorderImage()
 }
@@ -248,5 +282,8 @@ func (fm *blurPhotoFrame) getMagickCmdArgs(borderRadius int, saveFilePath string
 
 // 获取最终合成的图片.
 func (fm *blurPhotoFrame) drawBlurMerge() draw.Image {
+	// 画上,左,右边框中的文字与logo
+	fm.drawSideBands()
+
 	return fm.frameDraw
 }
@@ -1,7 +1,6 @@
 package native
 
 import (
-	"WaterMark/internal"
 	"WaterMark/pkg"
 )
 
@@ -16,47 +15,50 @@ func (b *autoBottomLogoTextAverageLayoutBorder) initLayoutValue(fm baseFrame) pk
 }
 
 // 计算文字布局与logo布局、分割线布局.
+// 第一个文字与第三个文字在左边分两行展示,第二个文字在右边居中展示.
 func (b *autoBottomLogoTextAverageLayoutBorder) setTextLayoutTextAndLogo(fm baseFrame) {
 	options := fm.getOptions()
 
 	imageX := options.getSourceImageX()
 
-	textOneContent := changeText2ExifContent(options.getExif(), options.Params.TextOneContent)
-	textTwoContent := changeText2ExifContent(options.getExif(), options.Params.TextTwoContent)
-	textThreeContent := changeText2ExifContent(options.getExif(), options.Params.TextThreeContent)
+	textOne := options.getText(TEXT_ONE)
+	textTwo := options.getText(TEXT_TWO)
+	textThree := options.getText(TEXT_THREE)
+	textOneContent := changeText2ExifContent(options.getExif(), textOne.Content)
+	textTwoContent := changeText2ExifContent(options.getExif(), textTwo.Content)
+	textThreeContent := changeText2ExifContent(options.getExif(), textThree.Content)
 	// 计算logo
 	b.getTextLayoutLogoCommonData(fm)
 	// 设置字体大小
 	b.setFontSize(fm, textOneContent, textTwoContent, textThreeContent)
 
 	twoTextWidth, twoTextHeight := getTextContentXAndY(
-		options.Params.TextTwoFontSize,
-		internal.GetFontFilePath(options.Params.TextTwoFontFile),
+		textTwo.FontSize,
+		getTextMeasure(textTwo),
 		textTwoContent,
 	)
 	// 字体布局
-	options.Params.TextTwoMarginLeft = imageX - twoTextWidth - options.Params.TextTwoFontSize
-	options.Params.TextTwoMarginTop = (options.Params.MainMarginBottom - options.Params.TextTwoFontSize) / 2
+	textTwo.MarginLeft = imageX - twoTextWidth - textTwo.FontSize - getQRCodeTextShowWidth(options)
+	textTwo.MarginTop = (options.Params.MainMarginBottom - textTwo.FontSize) / 2
 
 	_, oneTextHeight := getTextContentXAndY(
-		options.Params.TextOneFontSize,
-		internal.GetFontFilePath(options.Params.TextOneFontFile),
+		textOne.FontSize,
+		getTextMeasure(textOne),
 		textOneContent,
 	)
 
-	options.Params.TextOneMarginTop = (options.Params.MainMarginBottom-options.Params.TextOneFontSize)/3 +
-		(options.Params.TextOneFontSize-oneTextHeight)/2
-	options.Params.TextOneMarginLeft = options.Params.TextTwoFontSize
-	options.Params.TextThreeMarginTop = (options.Params.MainMarginBottom - options.Params.TextThreeFontSize) / 3 * 2
-	options.Params.TextThreeMarginLeft = options.Params.TextTwoFontSize
+	textOne.MarginTop = (options.Params.MainMarginBottom-textOne.FontSize)/3 + (textOne.FontSize-oneTextHeight)/2
+	textOne.MarginLeft = textTwo.FontSize
+	textThree.MarginTop = (options.Params.MainMarginBottom - textThree.FontSize) / 3 * 2
+	textThree.MarginLeft = textTwo.FontSize
 
 	// logo布局
-	options.Params.LogoMarginTop = options.Params.TextOneMarginTop + (options.Params.TextTwoFontSize - twoTextHeight)
-	options.Params.LogoMarginLeft = options.Params.TextTwoMarginLeft -
+	options.Params.LogoMarginTop = textOne.MarginTop + (textTwo.FontSize - twoTextHeight)
+	options.Params.LogoMarginLeft = textTwo.MarginLeft -
 		options.Params.LogoWidth*2
 
 	// 颜色
-	options.Params.SeparatorColor = SEPARATOR_COLOR
+	options.Params.SeparatorColor = getSeparatorColor(options.Params.SeparatorColor)
 	// 宽度
 	options.Params.SeparatorWidth = options.Params.LogoWidth / 40
 	// 高度
@@ -66,6 +68,18 @@ func (b *autoBottomLogoTextAverageLayoutBorder) setTextLayoutTextAndLogo(fm base
 	// 左边距
 	options.Params.SeparatorMarginLeft = options.Params.LogoMarginLeft +
 		options.Params.LogoWidth + options.Params.LogoWidth/2
+	b.setQRCodeMarginWithAverage(fm, imageX-textTwo.FontSize)
+}
+
+// 二维码展示在logo的左边,或者展示在第二个文字的右边,endX为第二个文字原本的结束位置.
+func (b *autoBottomLogoTextAverageLayoutBorder) setQRCodeMarginWithAverage(fm baseFrame, endX int) {
+	options := fm.getOptions()
+	qr := &options.Params.QRCode
+	marginLeft := endX - qr.Size
+	if isQRCodeNextToLogo(options) {
+		marginLeft = options.Params.LogoMarginLeft - getQRCodeSpacing(qr) - qr.Size
+	}
+	setQRCodeMargin(options, marginLeft, options.Params.LogoMarginTop, options.Params.LogoHeight)
 }
 
 // 设置字体大小.需要先根据图片尺寸计算出一个最大的fontSize,用于防止文字重叠.
@@ -75,33 +89,28 @@ func (b *autoBottomLogoTextAverageLayoutBorder) setFontSize(
 ) {
 	options := fm.getOptions()
 	imageX := options.getSourceImageX()
+	// 二维码的尺寸与logo高度一起计算,并且预留二维码的宽度
+	qrShowWidth := setQRCodeSize(options, options.Params.LogoHeight)
 
 	textContent := textOneContent + textTwoContent
-	textFontFile := internal.GetFontFilePath(options.Params.TextOneFontFile)
+	measure := getTextMeasure(options.getText(TEXT_ONE))
 	if len(textOneContent+textTwoContent) < len(textThreeContent+textTwoContent) {
 		textContent = textThreeContent + textTwoContent
 	}
+	if textContent == "" {
+		return
+	}
 	// 需要先根据图片尺寸计算出一个最大的fontSize,用于防止文字重叠
 	textContentMaxFontSize := getTextContentMaxSize(
-		imageX-options.Params.LogoWidth*5/2,
-		textFontFile,
+		imageX-options.Params.LogoWidth*5/2-qrShowWidth,
+		measure,
 		textContent,
 	)
 
-	// 字体大小
-	options.Params.TextOneFontSize = options.Params.MainMarginBottom * options.Params.TextOneFontSize / 100
-	options.Params.TextTwoFontSize = options.Params.MainMarginBottom * options.Params.TextTwoFontSize / 100
-	options.Params.TextThreeFontSize = options.Params.MainMarginBottom * options.Params.TextThreeFontSize / 100
-
-	// 重新赋值
-	if options.Params.TextOneFontSize > textContentMaxFontSize {
-		options.Params.TextOneFontSize = textContentMaxFontSize
-	}
-	if options.Params.TextTwoFontSize > textContentMaxFontSize {
-		options.Params.TextTwoFontSize = textContentMaxFontSize
-	}
-	if options.Params.TextThreeFontSize > textContentMaxFontSize {
-		options.Params.TextThreeFontSize = textContentMaxFontSize
+	// 字体大小为下边框高度的百分比,并且不超过最大的字体
+	for i := range options.Params.Texts {
+		text := &options.Params.Texts[i]
+		text.FontSize = min(options.Params.MainMarginBottom*text.FontSize/100, textContentMaxFontSize)
 	}
 }
 
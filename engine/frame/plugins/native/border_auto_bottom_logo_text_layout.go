@@ -1,7 +1,6 @@
 package native
 
 import (
-	"WaterMark/internal"
 	"WaterMark/layout"
 	"WaterMark/pkg"
 )
@@ -20,10 +19,16 @@ func (b *autoBottomLogoTextLayoutBorder) initLayoutValue(fm baseFrame) pkg.EErro
 	b.setTextLayoutBorder(fm)
 	// 自动计算logo
 	b.setTextLayoutLogo(fm)
+	// 计算镜头logo
+	b.setTextLayoutLensLogo(fm)
+	// 计算二维码
+	b.setTextLayoutQRCode(fm)
 	// 计算分隔符
 	b.setTextLayoutSeparator(fm)
 	// 计算文字
 	b.setTextLayoutText(fm)
+	// 二维码展示在文字旁边时,右边文字向左移动
+	b.setTextLayoutQRCodeWithText(fm)
 
 	// 判断是否右布局
 	if !b.IsRight {
@@ -46,7 +51,7 @@ func (b *autoBottomLogoTextLayoutBorder) getTextLayoutLogoCommonData(fm baseFram
 	options.Params.LogoHeight = options.Params.LogoRatio * options.Params.MainMarginBottom / 100
 	// 计算logo 100%展示对应的原始宽高
 	r1 := layout.GetLogoXAndYByNameAndHeight(
-		layout.GetLogoNameByMake(options.getMakeFromExif()),
+		options.getLogoName(),
 		options.Params.MainMarginBottom,
 	)
 	// 容错
@@ -55,7 +60,7 @@ func (b *autoBottomLogoTextLayoutBorder) getTextLayoutLogoCommonData(fm baseFram
 	}
 	// 计算logo实际展示对应的宽高
 	r2 := layout.GetLogoXAndYByNameAndHeight(
-		layout.GetLogoNameByMake(options.getMakeFromExif()),
+		options.getLogoName(),
 		options.Params.LogoHeight,
 	)
 	options.Params.LogoWidth = r2["width"]
@@ -93,20 +98,73 @@ func (b *autoBottomLogoTextLayoutBorder) setTextLayoutLogo(fm baseFrame) {
 	options.Params.LogoMarginRight = logoShowInfo.diffWidth - logoShowInfo.diffWidth/2
 }
 
+// 计算镜头logo,镜头logo展示在相机logo的右边.
+func (b *autoBottomLogoTextLayoutBorder) setTextLayoutLensLogo(fm baseFrame) {
+	options := fm.getOptions()
+	if setLensLogoSize(options, options.Params.LogoHeight) == 0 {
+		return
+	}
+	setLensLogoMargin(options)
+}
+
+// 计算二维码,二维码展示在logo旁边时紧跟在镜头logo的右边.
+func (b *autoBottomLogoTextLayoutBorder) setTextLayoutQRCode(fm baseFrame) {
+	options := fm.getOptions()
+	if setQRCodeSize(options, options.Params.LogoHeight) == 0 || !isQRCodeNextToLogo(options) {
+		return
+	}
+	b.setQRCodeMarginWithLogo(fm)
+}
+
+// 二维码紧跟在镜头logo的右边,与相机logo垂直居中.
+func (b *autoBottomLogoTextLayoutBorder) setQRCodeMarginWithLogo(fm baseFrame) {
+	options := fm.getOptions()
+	marginLeft := options.Params.LogoMarginLeft + options.Params.LogoWidth + getLensLogoShowWidth(options) +
+		getQRCodeSpacing(&options.Params.QRCode)
+	setQRCodeMargin(options, marginLeft, options.Params.LogoMarginTop, options.Params.LogoHeight)
+}
+
+// 二维码展示在右边文字的右边,右边文字向左移动二维码的宽度.
+func (b *autoBottomLogoTextLayoutBorder) setTextLayoutQRCodeWithText(fm baseFrame) {
+	options := fm.getOptions()
+	showWidth := getQRCodeShowWidth(options)
+	if showWidth == 0 || isQRCodeNextToLogo(options) {
+		return
+	}
+	// 没有右边文字时,二维码与照片右边的距离与logo左边距一致
+	endX := options.getSourceImageX() - max(options.Params.LogoMarginLeft, getQRCodeSpacing(&options.Params.QRCode))
+	if rightTextWidth := b.getRightTextMaxWidth(fm); rightTextWidth > 0 {
+		endX = b.getRightTextMinMarginLeft(fm) + rightTextWidth
+		for i := TEXT_TWO; i < len(options.Params.Texts); i += 2 {
+			options.Params.Texts[i].MarginLeft -= showWidth
+		}
+	}
+	setQRCodeMargin(options, endX-options.Params.QRCode.Size, options.Params.LogoMarginTop, options.Params.LogoHeight)
+}
+
+// 获取logo展示区域的完整宽度,包含镜头logo与展示在logo旁边的二维码.
+func (b *autoBottomLogoTextLayoutBorder) getLogoShowWidth(fm baseFrame) int {
+	options := fm.getOptions()
+
+	return options.Params.LogoMarginLeft + options.Params.LogoWidth + options.Params.LogoMarginRight +
+		getLensLogoShowWidth(options) + getQRCodeLogoShowWidth(options)
+}
+
 // 计算右布局下logo的展示位置.
 func (b *autoBottomLogoTextLayoutBorder) setTextLayoutLogoWithRight(fm baseFrame) {
 	options := fm.getOptions()
 	// 有分割线的情况下,使用分割线坐标计算logo展示位置
+	// 没有分割线的情况下,使用右边文字坐标计算logo展示位置
+	endX := b.getRightTextMinMarginLeft(fm)
 	if b.HasSeparator {
-		options.Params.LogoMarginLeft = options.Params.SeparatorMarginLeft -
-			options.Params.LogoMarginRight - options.Params.LogoWidth
-
-		return
+		endX = options.Params.SeparatorMarginLeft
+	}
+	options.Params.LogoMarginLeft = endX - options.Params.LogoMarginRight - options.Params.LogoWidth -
+		getLensLogoShowWidth(options) - getQRCodeLogoShowWidth(options)
+	setLensLogoMargin(options)
+	if getQRCodeLogoShowWidth(options) > 0 {
+		b.setQRCodeMarginWithLogo(fm)
 	}
-	// 没有分割线的情况下,使用右边文字坐标计算logo展示位置
-	textMarginLeft := min(options.Params.TextTwoMarginLeft, options.Params.TextFourMarginLeft)
-	options.Params.LogoMarginLeft = textMarginLeft -
-		options.Params.LogoMarginRight - options.Params.LogoWidth
 }
 
 // 计算分割线.
@@ -137,7 +195,7 @@ func (b *autoBottomLogoTextLayoutBorder) setTextLayoutSeparator(fm baseFrame) {
 		options.Params.SeparatorMarginBottom = options.Params.MainMarginBottom - options.Params.SeparatorMarginTop
 	}
 
-	logoShowWidth := options.Params.LogoMarginLeft + options.Params.LogoWidth + options.Params.LogoMarginRight
+	logoShowWidth := b.getLogoShowWidth(fm)
 
 	// 左边距
 	if options.Params.SeparatorMarginLeft == 0 {
@@ -157,7 +215,7 @@ func (b *autoBottomLogoTextLayoutBorder) setTextLayoutSeparatorWithRight(fm base
 		return
 	}
 
-	textMarginLeft := min(options.Params.TextTwoMarginLeft, options.Params.TextFourMarginLeft)
+	textMarginLeft := b.getRightTextMinMarginLeft(fm)
 
 	// 右边距
 	options.Params.SeparatorMarginRight = options.Params.LogoWidth / 5
@@ -182,8 +240,9 @@ func (b *autoBottomLogoTextLayoutBorder) setTextLayoutText(fm baseFrame) {
 func (b *autoBottomLogoTextLayoutBorder) setTextLayoutTextMarginLeftWithRight(fm baseFrame) {
 	options := fm.getOptions()
 	// 重新设置左边文字左边距
-	options.Params.TextOneMarginLeft = options.Params.LogoMarginLeft
-	options.Params.TextThreeMarginLeft = options.Params.LogoMarginLeft
+	for i := TEXT_ONE; i < len(options.Params.Texts); i += 2 {
+		options.Params.Texts[i].MarginLeft = options.Params.LogoMarginLeft
+	}
 }
 
 // 设置字体size.
@@ -192,43 +251,37 @@ func (b *autoBottomLogoTextLayoutBorder) setTextLayoutTextFontSize(fm baseFrame,
 	// 计算字体size
 
 	imageX := options.getSourceImageX()
-	textOneContent := changeText2ExifContent(options.getExif(), options.Params.TextOneContent)
-	textTwoContent := changeText2ExifContent(options.getExif(), options.Params.TextTwoContent)
-	textThreeContent := changeText2ExifContent(options.getExif(), options.Params.TextThreeContent)
-	textFourContent := changeText2ExifContent(options.getExif(), options.Params.TextFourContent)
-	textContent := textOneContent
-	if len(textOneContent) < len(textTwoContent) {
-		textContent = textTwoContent
+	// 偶数位置的文字在左边,奇数位置的文字在右边,每一行选择较长的文字拼接
+	textContent := ""
+	for i := TEXT_ONE; i < len(options.Params.Texts); i += 2 {
+		leftContent := changeText2ExifContent(options.getExif(), options.getText(i).Content)
+		rightContent := changeText2ExifContent(options.getExif(), options.getText(i+1).Content)
+		if len(leftContent) < len(rightContent) {
+			textContent += rightContent
+		} else {
+			textContent += leftContent
+		}
 	}
-	if len(textThreeContent) < len(textFourContent) {
-		textContent += textFourContent
-	} else {
-		textContent += textThreeContent
+	if textContent == "" {
+		return
 	}
-	textFileFont := internal.GetFontFilePath(options.Params.TextOneFontFile)
+	measure := getTextMeasure(options.getText(TEXT_ONE))
 
 	// 需要先根据图片尺寸计算出一个最大的fontSize,用于防止文字重叠
 	leftShowWidth := options.Params.LogoMarginLeft + options.Params.LogoWidth + options.Params.LogoMarginRight
 	textContentMaxFontSize := getTextContentMaxSize(
-		imageX-leftShowWidth*3,
-		textFileFont,
+		imageX-leftShowWidth*3-getLensLogoShowWidth(options)-getQRCodeShowWidth(options),
+		measure,
 		textContent,
 	)
 
 	// 从外部字体,计算得出的最大展示字体选择一个最小的作为实际字体使用
 	maxFontSize := min(fontSize, textContentMaxFontSize)
 
-	if options.Params.TextOneFontSize == 0 {
-		options.Params.TextOneFontSize = maxFontSize
-	}
-	if options.Params.TextTwoFontSize == 0 {
-		options.Params.TextTwoFontSize = maxFontSize
-	}
-	if options.Params.TextThreeFontSize == 0 {
-		options.Params.TextThreeFontSize = maxFontSize
-	}
-	if options.Params.TextFourFontSize == 0 {
-		options.Params.TextFourFontSize = maxFontSize
+	for i := range options.Params.Texts {
+		if options.Params.Texts[i].FontSize == 0 {
+			options.Params.Texts[i].FontSize = maxFontSize
+		}
 	}
 }
 
@@ -237,40 +290,20 @@ func (b *autoBottomLogoTextLayoutBorder) setTextLayoutTextMarginTop(fm baseFrame
 	options := fm.getOptions()
 
 	marginTop := options.Params.LogoMarginTop
-	// 兼容logo全展示情况
-	if marginTop == 0 {
-		marginTop = options.Params.LogoHeight / 5
-		if options.Params.TextOneMarginTop == 0 {
-			options.Params.TextOneMarginTop = marginTop*2 - options.Params.TextOneFontSize*72/96
+	for i := range options.Params.Texts {
+		text := &options.Params.Texts[i]
+		if text.MarginTop != 0 {
+			continue
 		}
-		if options.Params.TextTwoMarginTop == 0 {
-			options.Params.TextTwoMarginTop = marginTop*2 - options.Params.TextTwoFontSize*72/96
-		}
-
-		// 第二行文字上边距
-		if options.Params.TextThreeMarginTop == 0 {
-			options.Params.TextThreeMarginTop = marginTop * 3
+		// 第一行与第二行文字上边距
+		first := marginTop
+		second := marginTop + options.Params.LogoHeight - text.FontSize*72/96*2
+		// 兼容logo全展示情况
+		if marginTop == 0 {
+			first = options.Params.LogoHeight/5*2 - text.FontSize*72/96
+			second = options.Params.LogoHeight / 5 * 3
 		}
-		if options.Params.TextFourMarginTop == 0 {
-			options.Params.TextFourMarginTop = marginTop * 3
-		}
-
-		return
-	}
-	// 第一行文字上边距
-	if options.Params.TextOneMarginTop == 0 {
-		options.Params.TextOneMarginTop = marginTop
-	}
-	if options.Params.TextTwoMarginTop == 0 {
-		options.Params.TextTwoMarginTop = marginTop
-	}
-
-	// 第二行文字上边距
-	if options.Params.TextThreeMarginTop == 0 {
-		options.Params.TextThreeMarginTop = marginTop + options.Params.LogoHeight - options.Params.TextThreeFontSize*72/96*2
-	}
-	if options.Params.TextFourMarginTop == 0 {
-		options.Params.TextFourMarginTop = marginTop + options.Params.LogoHeight - options.Params.TextFourFontSize*72/96*2
+		text.MarginTop = getRowMarginTop(first, second, i/2)
 	}
 }
 
@@ -284,6 +317,10 @@ func (b *autoBottomLogoTextLayoutBorder) setTextLayoutTextMarginLeft(fm baseFram
 		leftShowWidth = options.Params.LogoMarginLeft
 	}
 	rightShowWidth := leftShowWidth
+	// 左布局下文字需要展示在镜头logo与二维码之后
+	if !b.IsRight {
+		leftShowWidth += getLensLogoShowWidth(options) + getQRCodeLogoShowWidth(options)
+	}
 	if b.HasSeparator {
 		leftShowWidth = options.Params.SeparatorMarginLeft +
 			options.Params.SeparatorWidth +
@@ -291,13 +328,13 @@ func (b *autoBottomLogoTextLayoutBorder) setTextLayoutTextMarginLeft(fm baseFram
 			options.Params.LogoMarginRight
 	}
 
-	if options.Params.TextOneMarginLeft == 0 {
-		options.Params.TextOneMarginLeft = leftShowWidth
-	}
-	if options.Params.TextThreeMarginLeft == 0 {
-		options.Params.TextThreeMarginLeft = leftShowWidth
+	// 左边文字
+	for i := TEXT_ONE; i < len(options.Params.Texts); i += 2 {
+		if options.Params.Texts[i].MarginLeft == 0 {
+			options.Params.Texts[i].MarginLeft = leftShowWidth
+		}
 	}
-	if options.Params.TextTwoMarginLeft > 0 && options.Params.TextFourMarginLeft > 0 {
+	if b.getRightTextMinMarginLeft(fm) > 0 {
 		return
 	}
 	// 计算右边文字内容的宽度
@@ -309,32 +346,37 @@ func (b *autoBottomLogoTextLayoutBorder) setTextLayoutTextMarginLeft(fm baseFram
 		marginLeft -= rightShowWidth
 	}
 	imageWidth := options.getSourceImageX()
-	if options.Params.TextTwoMarginLeft == 0 {
-		options.Params.TextTwoMarginLeft = imageWidth - marginLeft
-	}
-	if options.Params.TextFourMarginLeft == 0 {
-		options.Params.TextFourMarginLeft = imageWidth - marginLeft
+	// 右边文字
+	for i := TEXT_TWO; i < len(options.Params.Texts); i += 2 {
+		if options.Params.Texts[i].MarginLeft == 0 {
+			options.Params.Texts[i].MarginLeft = imageWidth - marginLeft
+		}
 	}
 }
 
 // 计算右边文字内容的宽度.
 func (b *autoBottomLogoTextLayoutBorder) getRightTextMaxWidth(fm baseFrame) int {
 	options := fm.getOptions()
-	twoTextContent := changeText2ExifContent(options.getExif(), options.Params.TextTwoContent)
-	twoTextContentWidth, _ := getTextContentXAndY(
-		options.Params.TextTwoFontSize,
-		internal.GetFontFilePath(options.Params.TextTwoFontFile),
-		twoTextContent,
-	)
+	maxWidth := 0
+	for i := TEXT_TWO; i < len(options.Params.Texts); i += 2 {
+		text := options.getText(i)
+		block := newTextElementBlock(text, changeText2ExifContent(options.getExif(), text.Content))
+		maxWidth = max(maxWidth, block.width)
+	}
 
-	fourTextContent := changeText2ExifContent(options.getExif(), options.Params.TextFourContent)
-	fourTextContentWidth, _ := getTextContentXAndY(
-		options.Params.TextFourFontSize,
-		internal.GetFontFilePath(options.Params.TextFourFontFile),
-		fourTextContent,
-	)
+	return maxWidth
+}
+
+// 获取右边文字中最小的左边距.
+func (b *autoBottomLogoTextLayoutBorder) getRightTextMinMarginLeft(fm baseFrame) int {
+	options := fm.getOptions()
+	// 与旧版本的四个文字保持一致,右边文字不存在时当作左边距为0
+	marginLeft := options.getText(TEXT_TWO).MarginLeft
+	for i := TEXT_TWO + 2; i < len(options.Params.Texts); i += 2 {
+		marginLeft = min(marginLeft, options.Params.Texts[i].MarginLeft)
+	}
 
-	return max(twoTextContentWidth, fourTextContentWidth)
+	return marginLeft
 }
 
 // 画边框.
@@ -0,0 +1,76 @@
+package native
+
+import (
+	"image/color"
+	"strings"
+
+	"WaterMark/pkg"
+)
+
+// 检查模板中的颜色是否是根据照片自动计算的颜色.
+func isAutoColor(s string) bool {
+	return strings.HasPrefix(s, AUTO_COLOR_PREFIX)
+}
+
+// 模板中的颜色转换为RGBA,自动颜色在加载照片之前使用默认颜色代替.
+func templateColor2RGBA(s, defaultColor string) color.RGBA {
+	if isAutoColor(s) {
+		return strColor2RGBA(defaultColor)
+	}
+
+	return strColor2RGBA(s)
+}
+
+// 获取分割线的颜色,自动颜色在加载照片之后计算,其它颜色使用默认的分割线颜色.
+func getSeparatorColor(s string) string {
+	if isAutoColor(s) {
+		return s
+	}
+
+	return SEPARATOR_COLOR
+}
+
+// 按照自动颜色的类型从调色板中获取颜色,不支持的类型使用主色.
+func getPaletteColor(palette pkg.Palette, s string) color.RGBA {
+	switch strings.TrimPrefix(s, AUTO_COLOR_PREFIX) {
+	case AUTO_COLOR_AVERAGE:
+		return palette.Average
+	case AUTO_COLOR_COMPLEMENT:
+		return palette.Complement()
+	default:
+		return palette.Dominant()
+	}
+}
+
+// 按照比例混合两个颜色.
+func mixColor(a, b color.RGBA, ratio float64) color.RGBA {
+	mix := func(x, y uint8) uint8 {
+		return uint8(float64(x)*(1-ratio) + float64(y)*ratio)
+	}
+
+	return color.RGBA{R: mix(a.R, b.R), G: mix(a.G, b.G), B: mix(a.B, b.B), A: mix(a.A, b.A)}
+}
+
+// 根据照片的调色板设置背景与分割线的颜色
+// 分割线颜色向背景的对比色混合,保证在背景上能够看清.
+func (fm *basePhotoFrame) setAutoColor() {
+	params := &fm.opts.Params
+	if !isAutoColor(params.BgColor) && !isAutoColor(params.SeparatorColor) {
+		return
+	}
+	if fm.srcImage.imgDecode == nil {
+		return
+	}
+	palette := pkg.NewPalette(fm.srcImage.imgDecode, pkg.PALETTE_COLOR_COUNT)
+	if isAutoColor(params.BgColor) {
+		fm.borImage.bgColor = getPaletteColor(palette, params.BgColor)
+	}
+	if fm.borImage.sepLay.isExist && isAutoColor(params.SeparatorColor) {
+		bgLuminance := pkg.ColorLuminance(fm.borImage.bgColor)
+		fm.borImage.sepLay.color = mixColor(
+			getPaletteColor(palette, params.SeparatorColor),
+			getContrastColor(bgLuminance),
+			AUTO_SEPARATOR_MIX_RATIO,
+		)
+	}
+}
@@ -0,0 +1,81 @@
+package native
+
+import (
+	"image"
+	"image/color"
+
+	"WaterMark/layout"
+	"WaterMark/pkg"
+)
+
+// 获取背景区域的相对亮度,普通边框使用背景色,模糊边框测量已经绘制的背景
+// 没有开启自动对比度或者背景无法测量时返回false.
+func (fm *basePhotoFrame) getBackgroundLuminance(rect image.Rectangle) (float64, bool) {
+	if !fm.opts.Params.AutoContrast {
+		return 0, false
+	}
+	if !fm.isBlur {
+		return pkg.ColorLuminance(fm.borImage.bgColor), true
+	}
+	if fm.frameDraw == nil {
+		return 0, false
+	}
+
+	return pkg.ImageLuminance(fm.frameDraw, rect)
+}
+
+// 获取与背景对比度最高的颜色,深色背景使用白色,浅色背景使用黑色.
+func getContrastColor(bgLuminance float64) color.RGBA {
+	if pkg.IsDarkLuminance(bgLuminance) {
+		return color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	}
+
+	return color.RGBA{A: 255}
+}
+
+// 文字颜色与背景的对比度不足时使用对比度最高的颜色.
+func getContrastTextColor(c color.Color, bgLuminance float64) color.Color {
+	if pkg.ContrastRatio(pkg.ColorLuminance(c), bgLuminance) >= AUTO_CONTRAST_MIN_RATIO {
+		return c
+	}
+
+	return getContrastColor(bgLuminance)
+}
+
+// 按照背景区域的亮度调整水印文字的颜色.
+func (fm *basePhotoFrame) setTextContrastColor(rect image.Rectangle) {
+	bgLuminance, ok := fm.getBackgroundLuminance(rect)
+	if !ok {
+		return
+	}
+	for i := range fm.borImage.textLay.list {
+		brush := fm.borImage.textLay.list[i].text
+		if brush.FontColor == nil {
+			continue
+		}
+		brush.FontColor = &image.Uniform{getContrastTextColor(brush.FontColor.C, bgLuminance)}
+	}
+}
+
+// 按照背景区域的亮度获取logo
+// 优先使用深浅色背景对应的logo,没有时如果logo与背景的对比度不足则重新着色.
+func (fm *basePhotoFrame) getContrastLogoImage(
+	name string, width, height int, rect image.Rectangle,
+) (*layout.Logo, pkg.EError) {
+	fill := fm.opts.getLogoColor()
+	bgLuminance, ok := fm.getBackgroundLuminance(rect)
+	if !ok || layout.CheckLogoIsUnSupport(name) {
+		return layout.GetColorLogoImageByNameAndWidhtAndHeight(name, width, height, fill)
+	}
+	if variant, found := layout.GetLogoVariantName(name, pkg.IsDarkLuminance(bgLuminance)); found {
+		return layout.GetColorLogoImageByNameAndWidhtAndHeight(variant, width, height, fill)
+	}
+	origin, err := layout.GetLogoImageByName(name)
+	// 模板指定了svg的颜色时不再自动着色
+	if pkg.HasError(err) || (fill != nil && origin.Ext == layout.LOGO_SVG_EXT) ||
+		pkg.ContrastRatio(origin.Luminance, bgLuminance) >= AUTO_CONTRAST_MIN_RATIO {
+		return layout.GetColorLogoImageByNameAndWidhtAndHeight(name, width, height, fill)
+	}
+
+	return layout.GetTintLogoImageByNameAndWidhtAndHeight(name, width, height, getContrastColor(bgLuminance))
+}
@@ -4,6 +4,7 @@ import (
 	"image"
 	"image/draw"
 
+	"WaterMark/layout"
 	"WaterMark/message"
 	"WaterMark/pkg"
 )
@@ -41,6 +42,60 @@ func (b *baseBottomLogoTextLayoutBorder) setTextLayoutBorder(fm baseFrame) {
 	options.Params.MainMarginRight = options.Params.MainMarginTop
 }
 
+// 计算第row行文字的上边距,第三行开始按照前两行的行距依次向下排列.
+func getRowMarginTop(first, second, row int) int {
+	if row == 0 {
+		return first
+	}
+
+	return second + (row-1)*(second-first)
+}
+
+// 居中布局,每个内容不为空的文字占一行(多行文字占多行),按照相同的字体大小居中展示,剩余空白部分平均分配到每行文字之间.
+func (b *baseBottomLogoTextLayoutBorder) setTextLayoutCenterLines(
+	fm baseFrame,
+	lines []*layout.TextElement,
+	fontSize int,
+) {
+	options := fm.getOptions()
+	imageX := options.getSourceImageX()
+
+	// 多行文字按照文字区域的宽高计算
+	blocks := make([]textBlock, len(lines))
+	blocksHeight := 0
+	for i, text := range lines {
+		text.FontSize = fontSize
+		blocks[i] = newTextElementBlock(text, changeText2ExifContent(options.getExif(), text.Content))
+		blocksHeight += blocks[i].getHeight()
+	}
+	diffHeight := (options.Params.MainMarginBottom - blocksHeight) / (len(lines) + 1)
+	marginTop := 0
+	for i, text := range lines {
+		text.MarginLeft = (imageX - blocks[i].width) / 2
+		text.MarginRight = imageX - text.MarginLeft
+		text.MarginTop = diffHeight*(i+1) + marginTop
+		marginTop += blocks[i].getHeight()
+	}
+}
+
+// 获取居中布局中最长的一行文字与对应的测量参数,用于计算最大的字体尺寸.
+func getLongestTextLine(fm baseFrame, lines []*layout.TextElement) (string, textMeasure) {
+	options := fm.getOptions()
+	textContent := ""
+	var measure textMeasure
+	for _, text := range lines {
+		// 多行文字按照每一行分别比较
+		for _, content := range splitTextParagraphs(changeText2ExifContent(options.getExif(), text.Content)) {
+			if len(measure.fontPaths) == 0 || len(textContent) < len(content) {
+				textContent = content
+				measure = getTextMeasure(text)
+			}
+		}
+	}
+
+	return textContent, measure
+}
+
 // 画分割线.
 func (b *baseBottomLogoTextLayoutBorder) drawSeparator(fm baseFrame) {
 	borImage := fm.getBorImage()
@@ -63,20 +118,32 @@ func (b *baseBottomLogoTextLayoutBorder) drawSeparator(fm baseFrame) {
 	}
 }
 
-// 画logo.
+// 画logo,存在镜头logo与二维码时一起画出.
 func (b *baseBottomLogoTextLayoutBorder) drawLogo(fm baseFrame) {
+	borImage := fm.getBorImage()
+	b.drawLogoItem(fm, &borImage.logoLay)
+	if borImage.lensLogoLay.item.IsLoad {
+		b.drawLogoItem(fm, &borImage.lensLogoLay)
+	}
+	if borImage.qrCodeLay.item.IsLoad {
+		b.drawLogoItem(fm, &borImage.qrCodeLay)
+	}
+}
+
+// 画指定的logo.
+func (b *baseBottomLogoTextLayoutBorder) drawLogoItem(fm baseFrame, logoLay *logoLayout) {
 	borImage := fm.getBorImage()
 	srcImage := fm.getSrcImage()
 
-	logo := borImage.logoLay.item
+	logo := logoLay.item
 	// 默认是左下布局
-	startX := borImage.logoLay.layout.marginLeft
+	startX := logoLay.layout.marginLeft
 	// 判断是否是右下布局
 	if b.IsRight {
-		startX = srcImage.width - borImage.logoLay.layout.marginRight
+		startX = srcImage.width - logoLay.layout.marginRight
 	}
 
-	startY := borImage.logoLay.layout.marginTop
+	startY := logoLay.layout.marginTop
 
 	if logo.Ext != ".png" {
 		drawBorderLogo(fm.getPhotoFrame(), logo.LogoImage, startX, startY, startX+logo.Width, startY+logo.Height)
@@ -95,18 +162,21 @@ func (b *baseBottomLogoTextLayoutBorder) drawWords(fm baseFrame) {
 	borImage := fm.getBorImage()
 	srcImage := fm.getSrcImage()
 	options := fm.getOptions()
-	for _, textMark := range borImage.textLay.list {
-		// 默认是左下布局
-		margin := textMark.layout.marginLeft
+	for i := range borImage.textLay.list {
+		textMark := &borImage.textLay.list[i]
+		content := changeText2ExifContent(options.getExif(), textMark.words)
+		block := textMark.newTextBlock(content)
+		// 默认是左下布局,按照文字的对齐方式计算
+		margin := textMark.getStartX(srcImage.width, block.width)
 		// 判断是否是右下布局
 		if b.IsRight {
 			margin = srcImage.width - textMark.layout.marginRight
 		}
 
-		err := textMark.text.drawFontOnRGBA(
+		err := textMark.drawLines(
 			fm.getBorderDraw(),
-			image.Pt(margin, textMark.layout.marginTop),
-			changeText2ExifContent(options.getExif(), textMark.words),
+			block.getLines(textMark.align, margin, textMark.layout.marginTop),
+			image.Point{},
 		)
 		// 发生错误,发送错误信息
 		if pkg.HasError(err) {
@@ -3,7 +3,7 @@ package native
 import (
 	"image"
 
-	"WaterMark/internal"
+	"WaterMark/layout"
 	"WaterMark/message"
 	"WaterMark/pkg"
 )
@@ -18,21 +18,16 @@ func (b *blurBottomTextCenterLayout) initLayoutValue(fm baseFrame) pkg.EError {
 }
 
 // 获取最大的字体尺寸.
-func (b *blurBottomTextCenterLayout) getMaxFontSize(fm baseFrame, textOneContent, textThreeContent string) int {
+func (b *blurBottomTextCenterLayout) getMaxFontSize(fm baseFrame, lines []*layout.TextElement) int {
 	options := fm.getOptions()
 
 	imageX := options.getSourceImageX()
 	// 需要先根据图片尺寸计算出一个最大的fontSize,用于防止文字重叠
-	textContent := textOneContent
-	textFontFile := internal.GetFontFilePath(options.Params.TextOneFontFile)
-	if len(textOneContent) < len(textThreeContent) {
-		textContent = textThreeContent
-		textFontFile = internal.GetFontFilePath(options.Params.TextThreeFontFile)
-	}
+	textContent, measure := getLongestTextLine(fm, lines)
 
 	textContentMaxFontSize := getTextContentMaxSize(
 		imageX,
-		textFontFile,
+		measure,
 		textContent,
 	)
 
@@ -43,39 +38,15 @@ func (b *blurBottomTextCenterLayout) getMaxFontSize(fm baseFrame, textOneContent
 func (b *blurBottomTextCenterLayout) setTextLayoutText(fm baseFrame) {
 	options := fm.getOptions()
 
-	imageX := options.getSourceImageX()
-
-	textThreeContent := changeText2ExifContent(options.getExif(), options.Params.TextThreeContent)
-	textOneContent := changeText2ExifContent(options.getExif(), options.Params.TextOneContent)
-
+	lines := options.getTextLines()
+	if len(lines) == 0 {
+		return
+	}
 	// 需要先根据图片尺寸计算出一个最大的fontSize,用于防止文字重叠
-	showHeight := b.getMaxFontSize(fm, textOneContent, textThreeContent)
+	showHeight := b.getMaxFontSize(fm, lines)
 
-	// 第二行文学信息
-	options.Params.TextThreeFontSize = showHeight
-	threeTextWidth, _ := getTextContentXAndY(
-		options.Params.TextThreeFontSize,
-		internal.GetFontFilePath(options.Params.TextThreeFontFile),
-		textThreeContent,
-	)
-	options.Params.TextThreeMarginLeft = (imageX - threeTextWidth) / 2
-	options.Params.TextThreeMarginRight = imageX - options.Params.TextThreeMarginLeft
-
-	// 将剩余空白部分三等分,用于计算文字的上边距
-	diffHeight := (options.Params.MainMarginBottom - options.Params.TextThreeFontSize*2) / 3
-
-	options.Params.TextThreeMarginTop = diffHeight*2 + options.Params.TextThreeFontSize
-
-	// 第一行文学信息
-	options.Params.TextOneFontSize = options.Params.TextThreeFontSize
-	oneTextWidth, _ := getTextContentXAndY(
-		options.Params.TextOneFontSize,
-		internal.GetFontFilePath(options.Params.TextOneFontFile),
-		textOneContent,
-	)
-	options.Params.TextOneMarginLeft = (imageX - oneTextWidth) / 2
-	options.Params.TextOneMarginRight = imageX - options.Params.TextOneMarginLeft
-	options.Params.TextOneMarginTop = diffHeight
+	// 每一行文字居中展示
+	b.setTextLayoutCenterLines(fm, lines, showHeight)
 }
 
 // 绘制文字.
@@ -86,14 +57,14 @@ func (b *blurBottomTextCenterLayout) drawBorder(fm baseFrame) pkg.EError {
 	// 获取照片高度
 	h := options.getSourceImageY()
 	// 画水印文字
-	for _, textMark := range borImage.textLay.list {
-		// 默认是左下布局
-		margin := textMark.layout.marginLeft + borImage.leftWidth
-
-		err := textMark.text.drawFontOnRGBA(
+	for i := range borImage.textLay.list {
+		textMark := &borImage.textLay.list[i]
+		content := changeText2ExifContent(options.getExif(), textMark.words)
+		// 按照文字的对齐方式计算
+		err := textMark.drawLines(
 			frameDraw,
-			image.Pt(margin, textMark.layout.marginTop+h+borImage.topHeight),
-			changeText2ExifContent(options.getExif(), textMark.words),
+			textMark.getLines(options.getSourceImageX(), content),
+			image.Pt(borImage.leftWidth, h+borImage.topHeight),
 		)
 		// 发生错误,发送错误信息
 		if pkg.HasError(err) {
@@ -0,0 +1,84 @@
+package native
+
+import (
+	"WaterMark/layout"
+	"WaterMark/pkg"
+)
+
+// 计算镜头logo的宽高,返回镜头logo与间距的总宽度,不展示镜头logo时返回0.
+func setLensLogoSize(options *frameOption, logoHeight int) int {
+	lens := &options.Params.LensLogo
+	lens.Width, lens.Height = 0, 0
+	logoName := options.getLensLogoName()
+	if layout.CheckLogoIsUnSupport(logoName) {
+		return 0
+	}
+	ratio := lens.Ratio
+	if ratio <= 0 {
+		ratio = 100
+	}
+	lens.Height = logoHeight * ratio / 100
+	lens.Width = layout.GetLogoXAndYByNameAndHeight(logoName, lens.Height)["width"]
+
+	return getLensLogoShowWidth(options)
+}
+
+// 获取镜头logo与间距的总宽度.
+func getLensLogoShowWidth(options *frameOption) int {
+	lens := &options.Params.LensLogo
+	if lens.Width <= 0 || lens.Height <= 0 {
+		return 0
+	}
+
+	return lens.Width + getLensLogoSpacing(lens)
+}
+
+// 获取镜头logo与相机logo之间的距离.
+func getLensLogoSpacing(lens *layout.LensLogo) int {
+	if lens.Spacing > 0 {
+		return lens.Spacing
+	}
+
+	return lens.Height / 2
+}
+
+// 镜头logo紧跟在相机logo的右边,两者垂直居中.
+func setLensLogoMargin(options *frameOption) {
+	lens := &options.Params.LensLogo
+	if lens.Width <= 0 || lens.Height <= 0 {
+		return
+	}
+	lens.MarginLeft = options.Params.LogoMarginLeft + options.Params.LogoWidth + getLensLogoSpacing(lens)
+	lens.MarginTop = options.Params.LogoMarginTop + (options.Params.LogoHeight-lens.Height)/2
+}
+
+// 镜头logo布局.
+func newLensLogoLayoutBox(params *layout.FrameLayout) layoutBox {
+	return layoutBox{
+		width:        params.LensLogo.Width,
+		height:       params.LensLogo.Height,
+		marginTop:    params.LensLogo.MarginTop,
+		marginRight:  params.LensLogo.MarginRight,
+		marginBottom: params.LensLogo.MarginBottom,
+		marginLeft:   params.LensLogo.MarginLeft,
+	}
+}
+
+// 加载指定尺寸的镜头logo,没有配置尺寸或者没有找到logo时不加载.
+func (fm *basePhotoFrame) loadLensLogo() pkg.EError {
+	box := fm.borImage.lensLogoLay.layout
+	if box.width <= 0 || box.height <= 0 {
+		return pkg.NoError
+	}
+	logoName := fm.opts.getLensLogoName()
+	if layout.CheckLogoIsUnSupport(logoName) {
+		return pkg.NoError
+	}
+	logo, err := fm.getContrastLogoImage(logoName, box.width, box.height, fm.getBorderRects()[SIDE_BOTTOM])
+	if pkg.HasError(err) {
+		return err
+	}
+	fm.borImage.lensLogoLay.item = logo
+
+	return pkg.NoError
+}
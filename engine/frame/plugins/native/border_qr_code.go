@@ -0,0 +1,180 @@
+package native
+
+import (
+	"image"
+	"image/color"
+	"strings"
+
+	"github.com/skip2/go-qrcode"
+
+	"WaterMark/layout"
+	"WaterMark/pkg"
+)
+
+// 计算二维码的尺寸,返回二维码与间距的总宽度,不展示二维码时返回0.
+func setQRCodeSize(options *frameOption, logoHeight int) int {
+	qr := &options.Params.QRCode
+	qr.Size = 0
+	if options.getQRCodeContent() == "" {
+		return 0
+	}
+	ratio := qr.Ratio
+	if ratio <= 0 {
+		ratio = 100
+	}
+	qr.Size = logoHeight * ratio / 100
+
+	return getQRCodeShowWidth(options)
+}
+
+// 获取二维码与间距的总宽度.
+func getQRCodeShowWidth(options *frameOption) int {
+	qr := &options.Params.QRCode
+	if qr.Size <= 0 {
+		return 0
+	}
+
+	return qr.Size + getQRCodeSpacing(qr)
+}
+
+// 获取展示在logo旁边的二维码与间距的总宽度,二维码展示在文字旁边时返回0.
+func getQRCodeLogoShowWidth(options *frameOption) int {
+	if !isQRCodeNextToLogo(options) {
+		return 0
+	}
+
+	return getQRCodeShowWidth(options)
+}
+
+// 获取展示在文字旁边的二维码与间距的总宽度,二维码展示在logo旁边时返回0.
+func getQRCodeTextShowWidth(options *frameOption) int {
+	if isQRCodeNextToLogo(options) {
+		return 0
+	}
+
+	return getQRCodeShowWidth(options)
+}
+
+// 获取二维码与相邻元素之间的距离.
+func getQRCodeSpacing(qr *layout.QRCode) int {
+	if qr.Spacing > 0 {
+		return qr.Spacing
+	}
+
+	return qr.Size / 2
+}
+
+// 二维码是否展示在logo旁边.
+func isQRCodeNextToLogo(options *frameOption) bool {
+	return options.Params.QRCode.Position == QR_CODE_POSITION_LOGO
+}
+
+// 设置二维码的左边距,并且与指定的区域垂直居中.
+func setQRCodeMargin(options *frameOption, marginLeft, top, height int) {
+	qr := &options.Params.QRCode
+	if qr.Size <= 0 {
+		return
+	}
+	qr.MarginLeft = marginLeft
+	qr.MarginTop = max(0, top+(height-qr.Size)/2)
+}
+
+// 二维码布局.
+func newQRCodeLayoutBox(params *layout.FrameLayout) layoutBox {
+	return layoutBox{
+		width:        params.QRCode.Size,
+		height:       params.QRCode.Size,
+		marginTop:    params.QRCode.MarginTop,
+		marginRight:  params.QRCode.MarginRight,
+		marginBottom: params.QRCode.MarginBottom,
+		marginLeft:   params.QRCode.MarginLeft,
+	}
+}
+
+// 获取二维码的容错级别.
+func getQRCodeRecoveryLevel(level string) qrcode.RecoveryLevel {
+	switch strings.ToUpper(level) {
+	case "L":
+		return qrcode.Low
+	case "Q":
+		return qrcode.High
+	case "H":
+		return qrcode.Highest
+	default:
+		return qrcode.Medium
+	}
+}
+
+// 生成指定尺寸的二维码图片,背景颜色为空时背景透明.
+func newQRCodeImage(content string, qr *layout.QRCode, fgColor color.RGBA) (image.Image, pkg.EError) {
+	code, err := qrcode.New(content, getQRCodeRecoveryLevel(qr.Level))
+	if err != nil {
+		return nil, pkg.NewErrors(pkg.IMAGE_QR_CODE_ERROR, err.Error())
+	}
+	code.DisableBorder = true
+	bitmap := code.Bitmap()
+	quietZone := max(0, qr.QuietZone)
+	modules := len(bitmap) + quietZone*2
+
+	bgColor := color.RGBA{}
+	if qr.BgColor != "" {
+		bgColor = strColor2RGBA(qr.BgColor)
+	}
+	img := image.NewRGBA(image.Rect(0, 0, qr.Size, qr.Size))
+	for y := range qr.Size {
+		row := y*modules/qr.Size - quietZone
+		for x := range qr.Size {
+			col := x*modules/qr.Size - quietZone
+			c := bgColor
+			if row >= 0 && row < len(bitmap) && col >= 0 && col < len(bitmap) && bitmap[row][col] {
+				c = fgColor
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+
+	return img, pkg.NoError
+}
+
+// 获取二维码的颜色,没有配置时使用第一行文字调整对比度之后的颜色.
+func (fm *basePhotoFrame) getQRCodeColor() color.RGBA {
+	qr := &fm.opts.Params.QRCode
+	if qr.Color != "" {
+		return strColor2RGBA(qr.Color)
+	}
+	for i := range fm.borImage.textLay.list {
+		brush := fm.borImage.textLay.list[i].text
+		if brush != nil && brush.FontColor != nil {
+			return color.RGBAModel.Convert(brush.FontColor.C).(color.RGBA)
+		}
+	}
+
+	return color.RGBA{A: 255}
+}
+
+// 加载二维码,没有配置尺寸或者内容为空时不加载.
+func (fm *basePhotoFrame) loadQRCode() pkg.EError {
+	box := fm.borImage.qrCodeLay.layout
+	if box.width <= 0 {
+		return pkg.NoError
+	}
+	content := fm.opts.getQRCodeContent()
+	if content == "" {
+		return pkg.NoError
+	}
+	img, err := newQRCodeImage(content, &fm.opts.Params.QRCode, fm.getQRCodeColor())
+	if pkg.HasError(err) {
+		return err
+	}
+	// png格式的logo在绘制时会先填充边框背景颜色
+	fm.borImage.qrCodeLay.item = &layout.Logo{
+		LogoImage: img,
+		Name:      "qr_code",
+		Ext:       ".png",
+		Width:     box.width,
+		Height:    box.height,
+		IsLoad:    true,
+	}
+
+	return pkg.NoError
+}
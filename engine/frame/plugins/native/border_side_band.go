@@ -0,0 +1,252 @@
+package native
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/disintegration/imaging"
+
+	"WaterMark/layout"
+	"WaterMark/message"
+	"WaterMark/pkg"
+)
+
+// 边框中横排绘制的内容,左右边框绘制完成之后再旋转.
+type sideBandStrip struct {
+	canvas    *image.RGBA
+	logo      *layout.Logo
+	brush     *textBrush
+	words     string
+	length    int
+	thickness int
+	textWidth int
+	fontSize  int
+}
+
+// 获取照片四周边框在最终图片中所在的区域.
+func (fm *basePhotoFrame) getBorderRects() map[string]image.Rectangle {
+	width := fm.borImage.leftWidth + fm.srcImage.width + fm.borImage.rightWidth
+	top := fm.borImage.topHeight
+	bottom := top + fm.srcImage.height
+
+	return map[string]image.Rectangle{
+		SIDE_TOP:    image.Rect(0, 0, width, top),
+		SIDE_BOTTOM: image.Rect(0, bottom, width, bottom+fm.borImage.bottomHeight),
+		SIDE_LEFT:   image.Rect(0, top, fm.borImage.leftWidth, bottom),
+		SIDE_RIGHT:  image.Rect(width-fm.borImage.rightWidth, top, width, bottom),
+	}
+}
+
+// 获取照片四周边框的位置与尺寸.
+func (fm *basePhotoFrame) getBorderBoxes() map[string]map[string]int {
+	boxes := make(map[string]map[string]int, 4)
+	for side, rect := range fm.getBorderRects() {
+		boxes[side] = map[string]int{
+			"x":      rect.Min.X,
+			"y":      rect.Min.Y,
+			"width":  rect.Dx(),
+			"height": rect.Dy(),
+		}
+	}
+
+	return boxes
+}
+
+// 获取指定位置边框的配置.
+func (fm *basePhotoFrame) getSideBand(side string) *layout.SideBand {
+	switch side {
+	case SIDE_LEFT:
+		return &fm.opts.Params.LeftBand
+	case SIDE_RIGHT:
+		return &fm.opts.Params.RightBand
+	default:
+		return &fm.opts.Params.TopBand
+	}
+}
+
+// 画上,左,右边框中的文字与logo.
+func (fm *basePhotoFrame) drawSideBands() {
+	rects := fm.getBorderRects()
+	for _, side := range sideBandList {
+		band := fm.getSideBand(side)
+		if band.Content == "" && band.LogoRatio <= 0 {
+			continue
+		}
+		if rects[side].Empty() {
+			continue
+		}
+		err := fm.drawSideBand(side, band, rects[side])
+		// 发生错误,发送错误信息
+		if pkg.HasError(err) {
+			message.SendErrorMsg(err.String())
+		}
+	}
+}
+
+// 画指定位置边框中的文字与logo,左边框文字从下往上阅读,右边框文字从上往下阅读.
+func (fm *basePhotoFrame) drawSideBand(side string, band *layout.SideBand, rect image.Rectangle) pkg.EError {
+	length, thickness := rect.Dx(), rect.Dy()
+	if side != SIDE_TOP {
+		length, thickness = rect.Dy(), rect.Dx()
+	}
+	strip := &sideBandStrip{
+		canvas:    loadImageRGBA(0, 0, length, thickness),
+		length:    length,
+		thickness: thickness,
+	}
+	if err := fm.setSideBandLogo(strip, band, rect); pkg.HasError(err) {
+		return err
+	}
+	if err := fm.setSideBandText(strip, band, rect); pkg.HasError(err) {
+		return err
+	}
+	if err := strip.drawContent(band.Align); pkg.HasError(err) {
+		return err
+	}
+
+	var img image.Image = strip.canvas
+	switch side {
+	case SIDE_LEFT:
+		img = imaging.Rotate90(strip.canvas)
+	case SIDE_RIGHT:
+		img = imaging.Rotate270(strip.canvas)
+	}
+	draw.Draw(fm.frameDraw, rect, img, image.Point{}, draw.Over)
+
+	return pkg.NoError
+}
+
+// 计算边框中logo的尺寸并加载logo.
+func (fm *basePhotoFrame) setSideBandLogo(
+	strip *sideBandStrip, band *layout.SideBand, rect image.Rectangle,
+) pkg.EError {
+	if band.LogoRatio <= 0 {
+		return pkg.NoError
+	}
+	logoName := fm.opts.getLogoName()
+	size := layout.GetLogoXAndYByNameAndHeight(logoName, strip.thickness*min(band.LogoRatio, 100)/100)
+	if size["width"] == 0 || size["height"] == 0 {
+		return pkg.NoError
+	}
+	logo, err := fm.getContrastLogoImage(logoName, size["width"], size["height"], rect)
+	if pkg.HasError(err) {
+		return err
+	}
+	strip.logo = logo
+
+	return pkg.NoError
+}
+
+// 计算边框中文字的字体大小与宽度,自动计算的字体大小不会超出边框的长度.
+func (fm *basePhotoFrame) setSideBandText(
+	strip *sideBandStrip, band *layout.SideBand, rect image.Rectangle,
+) pkg.EError {
+	strip.words = changeText2ExifContent(fm.opts.getExif(), band.Content)
+	if strip.words == "" {
+		return pkg.NoError
+	}
+	fontFile, fallbacks := fm.getSideBandFont(band)
+	measure := newTextMeasure(fontFile, fallbacks)
+	fontColor := band.FontColor
+	if fontColor == "" {
+		fontColor = fm.opts.getText(TEXT_ONE).FontColor
+	}
+	fontSize := strip.getFontSize(band, measure)
+	var textColor color.Color = strColor2RGBA(fontColor)
+	if bgLuminance, ok := fm.getBackgroundLuminance(rect); ok {
+		textColor = getContrastTextColor(textColor, bgLuminance)
+	}
+	brush, err := newTextBrush(fontFile, float64(fontSize), &image.Uniform{textColor}, fallbacks...)
+	if pkg.HasError(err) {
+		return err
+	}
+	strip.brush = brush
+	strip.fontSize = fontSize
+	strip.textWidth, _ = getTextContentXAndY(fontSize, measure, strip.words)
+
+	return pkg.NoError
+}
+
+// 获取边框文字的字体与备用字体,没有指定字体时使用第一行文字的字体与备用字体.
+func (fm *basePhotoFrame) getSideBandFont(band *layout.SideBand) (string, []string) {
+	if band.FontFile == "" {
+		text := fm.opts.getText(TEXT_ONE)
+
+		return text.FontFile, text.FallbackFonts
+	}
+	if len(band.FallbackFonts) > 0 {
+		return band.FontFile, band.FallbackFonts
+	}
+
+	return band.FontFile, fm.opts.Params.FallbackFonts
+}
+
+// 计算边框中文字的字体大小,没有指定时按照边框宽度计算.
+func (strip *sideBandStrip) getFontSize(band *layout.SideBand, measure textMeasure) int {
+	if band.FontSize > 0 {
+		return band.FontSize
+	}
+	ratio := band.TextRatio
+	if ratio <= 0 {
+		ratio = SIDE_BAND_TEXT_RATIO
+	}
+	fontSize := strip.thickness * ratio / 100
+	// 文字较短时按照长度计算的字体会非常大,只有超出边框长度时才按照长度计算
+	available := strip.length - strip.getPadding()*2 - strip.getLogoShowWidth()
+	if width, _ := getTextContentXAndY(fontSize, measure, strip.words); width > available {
+		fontSize = min(
+			fontSize,
+			getTextContentMaxSize(max(available, 1), measure, strip.words),
+		)
+	}
+
+	return fontSize
+}
+
+// 边框内容距离边框两端的距离.
+func (strip *sideBandStrip) getPadding() int {
+	return strip.thickness / 4
+}
+
+// 获取logo与其后间隔的宽度.
+func (strip *sideBandStrip) getLogoShowWidth() int {
+	if strip.logo == nil {
+		return 0
+	}
+
+	return strip.logo.Width + strip.logo.Height/2
+}
+
+// 按照对齐方式绘制logo与文字,logo在文字之前,两者在边框宽度方向上居中.
+func (strip *sideBandStrip) drawContent(align string) pkg.EError {
+	contentWidth := strip.getLogoShowWidth() + strip.textWidth
+	if strip.words == "" && strip.logo != nil {
+		contentWidth = strip.logo.Width
+	}
+	startX := (strip.length - contentWidth) / 2
+	switch align {
+	case SIDE_ALIGN_START:
+		startX = strip.getPadding()
+	case SIDE_ALIGN_END:
+		startX = strip.length - strip.getPadding() - contentWidth
+	}
+	if strip.logo != nil {
+		startY := (strip.thickness - strip.logo.Height) / 2
+		draw.Draw(
+			strip.canvas,
+			image.Rect(startX, startY, startX+strip.logo.Width, startY+strip.logo.Height),
+			strip.logo.LogoImage,
+			strip.logo.LogoImage.Bounds().Min,
+			draw.Over,
+		)
+		startX += strip.getLogoShowWidth()
+	}
+	if strip.words == "" {
+		return pkg.NoError
+	}
+	// 文字的基线位于起始坐标加上字体大小的位置,按照字体可见高度居中
+	startY := (strip.thickness+strip.fontSize*72/96)/2 - strip.fontSize
+
+	return strip.brush.drawFontOnRGBA(strip.canvas, image.Pt(startX, startY), strip.words)
+}
@@ -1,7 +1,6 @@
 package native
 
 import (
-	"WaterMark/internal"
 	"WaterMark/layout"
 	"WaterMark/pkg"
 )
@@ -17,27 +16,20 @@ func (b *simpleBottomLogoTextCenterBorder) initLayoutValue(fm baseFrame) pkg.EEr
 }
 
 // 获取最大的字体尺寸.
-func (b *simpleBottomLogoTextCenterBorder) getMaxFontSize(
-	fm baseFrame,
-	textOneContent, textThreeContent string,
-) int {
+func (b *simpleBottomLogoTextCenterBorder) getMaxFontSize(fm baseFrame, lines []*layout.TextElement) int {
 	options := fm.getOptions()
 
 	imageX := options.getSourceImageX()
 	// 需要先根据图片尺寸计算出一个最大的fontSize,用于防止文字重叠
-	textContent := textOneContent
-	textFontFile := internal.GetFontFilePath(options.Params.TextOneFontFile)
-	if len(textOneContent) < len(textThreeContent) {
-		textContent = textThreeContent
-		textFontFile = internal.GetFontFilePath(options.Params.TextThreeFontFile)
-	}
+	textContent, measure := getLongestTextLine(fm, lines)
 
 	if b.HasLogo {
-		logoName := layout.GetLogoNameByMake(options.getMakeFromExif())
+		logoName := options.getLogoName()
+		// 存在镜头logo时按照最大的字体预留镜头logo的宽度
 		textContentMaxFontSize := getTextContentMaxSizeWithLogo(
-			imageX,
+			imageX-setLensLogoSize(options, options.Params.MainMarginBottom/5),
 			logoName,
-			textFontFile,
+			measure,
 			textContent,
 		)
 
@@ -46,7 +38,7 @@ func (b *simpleBottomLogoTextCenterBorder) getMaxFontSize(
 
 	textContentMaxFontSize := getTextContentMaxSize(
 		imageX,
-		textFontFile,
+		measure,
 		textContent,
 	)
 
@@ -56,54 +48,36 @@ func (b *simpleBottomLogoTextCenterBorder) getMaxFontSize(
 // 计算布局信息.
 func (b *simpleBottomLogoTextCenterBorder) setTextLayoutTextAndLogo(fm baseFrame) {
 	options := fm.getOptions()
-	imageX := options.getSourceImageX()
-
-	textThreeContent := changeText2ExifContent(options.getExif(), options.Params.TextThreeContent)
-	textOneContent := changeText2ExifContent(options.getExif(), options.Params.TextOneContent)
 
+	lines := options.getTextLines()
+	if len(lines) == 0 {
+		return
+	}
 	// 需要先根据图片尺寸计算出一个最大的fontSize,用于防止文字重叠
-	showHeight := b.getMaxFontSize(fm, textOneContent, textThreeContent)
+	showHeight := b.getMaxFontSize(fm, lines)
 
 	// 计算logo 宽高
 	logoShowInfo := layout.GetLogoXAndYByNameAndHeight(
-		layout.GetLogoNameByMake(options.getMakeFromExif()),
+		options.getLogoName(),
 		showHeight,
 	)
-	// 第二行文学信息
-	options.Params.TextThreeFontSize = logoShowInfo["height"]
-	threeTextWidth, _ := getTextContentXAndY(
-		options.Params.TextThreeFontSize,
-		internal.GetFontFilePath(options.Params.TextThreeFontFile),
-		textThreeContent,
-	)
-	options.Params.TextThreeMarginLeft = (imageX - threeTextWidth) / 2
-	options.Params.TextThreeMarginRight = imageX - options.Params.TextThreeMarginLeft
-
-	// 将剩余空白部分三等分,用于计算文字的上边距
-	diffHeight := (options.Params.MainMarginBottom - options.Params.TextThreeFontSize*2) / 3
-
-	options.Params.TextThreeMarginTop = diffHeight*2 + options.Params.TextThreeFontSize
-
-	// 第一行文学信息
-	options.Params.TextOneFontSize = options.Params.TextThreeFontSize
-	oneTextWidth, _ := getTextContentXAndY(
-		options.Params.TextOneFontSize,
-		internal.GetFontFilePath(options.Params.TextOneFontFile),
-		textOneContent,
-	)
-	options.Params.TextOneMarginLeft = (imageX - oneTextWidth) / 2
-	options.Params.TextOneMarginRight = imageX - options.Params.TextOneMarginLeft
-	options.Params.TextOneMarginTop = diffHeight
+	// 每一行文字居中展示
+	b.setTextLayoutCenterLines(fm, lines, logoShowInfo["height"])
 
 	if !b.HasLogo {
 		return
 	}
 
-	b.setTextLayoutWithHasLogo(fm, logoShowInfo)
+	b.setTextLayoutWithHasLogo(fm, lines[0], logoShowInfo)
+	b.setTextLayoutLensLogo(fm, lines[0])
 }
 
-// 计算存在logo情况下的文字与logo布局.
-func (b *simpleBottomLogoTextCenterBorder) setTextLayoutWithHasLogo(fm baseFrame, logoShowInfo map[string]int) {
+// 计算存在logo情况下的文字与logo布局,logo展示在第一行文字的左边.
+func (b *simpleBottomLogoTextCenterBorder) setTextLayoutWithHasLogo(
+	fm baseFrame,
+	text *layout.TextElement,
+	logoShowInfo map[string]int,
+) {
 	options := fm.getOptions()
 
 	imageX := options.getSourceImageX()
@@ -111,21 +85,21 @@ func (b *simpleBottomLogoTextCenterBorder) setTextLayoutWithHasLogo(fm baseFrame
 	options.Params.LogoHeight = logoShowInfo["height"]
 	options.Params.LogoWidth = logoShowInfo["width"]
 
-	oneTextWidth, oneTextHeight := getTextContentXAndY(
-		options.Params.TextOneFontSize,
-		internal.GetFontFilePath(options.Params.TextOneFontFile),
-		changeText2ExifContent(options.getExif(), options.Params.TextOneContent),
+	textWidth, textHeight := getTextContentXAndY(
+		text.FontSize,
+		getTextMeasure(text),
+		changeText2ExifContent(options.getExif(), text.Content),
 	)
 
-	options.Params.LogoMarginTop = options.Params.TextOneMarginTop + (options.Params.TextOneFontSize-oneTextHeight)/2
+	options.Params.LogoMarginTop = text.MarginTop + (text.FontSize-textHeight)/2
 
-	options.Params.TextOneMarginLeft = (imageX - oneTextWidth + 2*options.Params.LogoWidth) / 2
-	options.Params.TextOneMarginRight = imageX - options.Params.TextOneMarginLeft
+	text.MarginLeft = (imageX - textWidth + 2*options.Params.LogoWidth) / 2
+	text.MarginRight = imageX - text.MarginLeft
 
-	options.Params.LogoMarginLeft = imageX - oneTextWidth - options.Params.TextOneMarginLeft - options.Params.LogoWidth
+	options.Params.LogoMarginLeft = imageX - textWidth - text.MarginLeft - options.Params.LogoWidth
 
 	// 颜色
-	options.Params.SeparatorColor = SEPARATOR_COLOR
+	options.Params.SeparatorColor = getSeparatorColor(options.Params.SeparatorColor)
 	// 宽度
 	options.Params.SeparatorWidth = options.Params.LogoWidth / 40
 	// 高度
@@ -133,7 +107,21 @@ func (b *simpleBottomLogoTextCenterBorder) setTextLayoutWithHasLogo(fm baseFrame
 	// 上边距
 	options.Params.SeparatorMarginTop = options.Params.LogoMarginTop
 	// 左边距
-	options.Params.SeparatorMarginLeft = options.Params.TextOneMarginLeft - options.Params.LogoWidth
+	options.Params.SeparatorMarginLeft = text.MarginLeft - options.Params.LogoWidth
+}
+
+// 计算镜头logo,镜头logo展示在相机logo与分割线之间,文字与相机logo分别向两边移动.
+func (b *simpleBottomLogoTextCenterBorder) setTextLayoutLensLogo(fm baseFrame, text *layout.TextElement) {
+	options := fm.getOptions()
+	lensShowWidth := setLensLogoSize(options, options.Params.LogoHeight)
+	if lensShowWidth == 0 {
+		return
+	}
+	text.MarginLeft += lensShowWidth / 2
+	text.MarginRight = options.getSourceImageX() - text.MarginLeft
+	options.Params.LogoMarginLeft -= lensShowWidth - lensShowWidth/2
+	options.Params.SeparatorMarginLeft = text.MarginLeft - options.Params.LogoWidth
+	setLensLogoMargin(options)
 }
 
 // 画边框.
@@ -5,15 +5,16 @@ import (
 	"image"
 	"image/color"
 	"image/draw"
-	"image/jpeg"
 	"image/png"
+	"io"
 	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
 
 	"github.com/fogleman/gg"
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/tiff"
 
 	"WaterMark/internal"
 	"WaterMark/layout"
@@ -56,7 +57,18 @@ var (
 // 获取文字内容对应的width,每次都需要重新计算.
 //
 //nolint:gocritic
-func getTextContentSize(fontSize int, fontFile, content string) (int, int) {
+func getTextContentSize(fontSize int, measure textMeasure, content string) (int, int) {
+	// 字间距与描边会增加文字的宽度
+	if measure.hasEffect() {
+		width, height := getTextContentSize(fontSize, textMeasure{fontPaths: measure.fontPaths}, content)
+
+		return width + measure.getEffectWidth(fontSize, content), height
+	}
+	// 包含备用字体时按照每段文字对应的字体计算
+	if measure.hasFallbacks() {
+		return getFontChainContentSize(fontSize, measure, content)
+	}
+	fontFile := measure.fontPaths[0]
 	// 利用gg库计算文字宽度
 	width, height := fontSize*len(content), fontSize*2
 	dc := gg.NewContext(width, height)
@@ -72,13 +84,13 @@ func getTextContentSize(fontSize int, fontFile, content string) (int, int) {
 }
 
 // 根据字体与logo计算合适的字体大小.
-func getTextContentMaxSizeWithLogo(width int, logoName, fontFile, content string) int {
+func getTextContentMaxSizeWithLogo(width int, logoName string, measure textMeasure, content string) int {
 	textFontSizeWithLogoOnce.Do(func() {
 		textFontSizeWithLogoCache = &textContenWithLogotFontSizeCache{
 			cache: make(map[string]int),
 		}
 	})
-	key := pkg.GetStrMD5(fmt.Sprintf("%s%s%s%d", logoName, fontFile, content, width))
+	key := pkg.GetStrMD5(fmt.Sprintf("%s%s%s%d", logoName, measure.key(), content, width))
 
 	textFontSizeWithLogoCache.mtx.Lock()
 	v, ok := textFontSizeWithLogoCache.cache[key]
@@ -87,7 +99,7 @@ func getTextContentMaxSizeWithLogo(width int, logoName, fontFile, content string
 		return v
 	}
 
-	maxFontSize := findTextContentMaxSizeWithLogo(width, logoName, fontFile, content)
+	maxFontSize := findTextContentMaxSizeWithLogo(width, logoName, measure, content)
 
 	textFontSizeWithLogoCache.mtx.Lock()
 	textFontSizeWithLogoCache.cache[key] = maxFontSize
@@ -97,9 +109,9 @@ func getTextContentMaxSizeWithLogo(width int, logoName, fontFile, content string
 }
 
 // 根据字体与logo计算合适的字体大小.
-func findTextContentMaxSizeWithLogo(width int, logoName, fontFile, content string) int {
+func findTextContentMaxSizeWithLogo(width int, logoName string, measure textMeasure, content string) int {
 	maxFontSize := width / len(content)
-	w, _ := getTextContentSize(maxFontSize, fontFile, content)
+	w, _ := getTextContentSize(maxFontSize, measure, content)
 	logoShowInfo := layout.GetLogoXAndYByNameAndHeight(logoName, maxFontSize)
 
 	// 文字宽度+logo大于实际展示宽度
@@ -107,7 +119,7 @@ func findTextContentMaxSizeWithLogo(width int, logoName, fontFile, content strin
 		for range 3 {
 			maxFontSize = maxFontSize * 72 / 96
 			logoShowInfo = layout.GetLogoXAndYByNameAndHeight(logoName, maxFontSize)
-			w, _ = getTextContentSize(maxFontSize, fontFile, content)
+			w, _ = getTextContentSize(maxFontSize, measure, content)
 			if w+logoShowInfo["width"] < width {
 				break
 			}
@@ -121,7 +133,7 @@ func findTextContentMaxSizeWithLogo(width int, logoName, fontFile, content strin
 	for range 3 {
 		lastFontSize = maxFontSize
 		maxFontSize = maxFontSize * 96 / 72
-		w, _ = getTextContentSize(maxFontSize, fontFile, content)
+		w, _ = getTextContentSize(maxFontSize, measure, content)
 		logoShowInfo = layout.GetLogoXAndYByNameAndHeight(logoName, maxFontSize)
 
 		if w+logoShowInfo["width"] > width {
@@ -135,14 +147,14 @@ func findTextContentMaxSizeWithLogo(width int, logoName, fontFile, content strin
 }
 
 // 获取指定宽度,指定字体文件下的最大宽度.
-func getTextContentMaxSize(width int, fontFile, content string) int {
+func getTextContentMaxSize(width int, measure textMeasure, content string) int {
 	textFontSizeCacheOnce.Do(func() {
 		textFontSizeCache = &textContentFontSizeCache{
 			cache: make(map[string]int),
 		}
 	})
 
-	key := pkg.GetStrMD5(fmt.Sprintf("%s%s%d", fontFile, content, width))
+	key := pkg.GetStrMD5(fmt.Sprintf("%s%s%d", measure.key(), content, width))
 	textFontSizeCache.mtx.Lock()
 	v, ok := textFontSizeCache.cache[key]
 	textFontSizeCache.mtx.Unlock()
@@ -150,7 +162,7 @@ func getTextContentMaxSize(width int, fontFile, content string) int {
 	if ok {
 		return v
 	}
-	maxFontSize := findTextContentMaxSize(width, fontFile, content)
+	maxFontSize := findTextContentMaxSize(width, measure, content)
 
 	textFontSizeCache.mtx.Lock()
 	textFontSizeCache.cache[key] = maxFontSize
@@ -160,15 +172,15 @@ func getTextContentMaxSize(width int, fontFile, content string) int {
 }
 
 // 获取指定宽度,指定字体文件下的最大宽度.
-func findTextContentMaxSize(width int, fontFile, content string) int {
+func findTextContentMaxSize(width int, measure textMeasure, content string) int {
 	maxFontSize := width / len(content)
-	w, _ := getTextContentSize(maxFontSize, fontFile, content)
+	w, _ := getTextContentSize(maxFontSize, measure, content)
 
 	// 文字宽度大于实际展示宽度
 	if w >= width {
 		for range 3 {
 			maxFontSize = maxFontSize * 72 / 96
-			w, _ = getTextContentSize(maxFontSize, fontFile, content)
+			w, _ = getTextContentSize(maxFontSize, measure, content)
 			if w < width {
 				break
 			}
@@ -181,7 +193,7 @@ func findTextContentMaxSize(width int, fontFile, content string) int {
 	for range 3 {
 		lastFontSize = maxFontSize
 		maxFontSize = maxFontSize * 96 / 72
-		w, _ = getTextContentSize(maxFontSize, fontFile, content)
+		w, _ = getTextContentSize(maxFontSize, measure, content)
 		if w > width {
 			maxFontSize = lastFontSize
 
@@ -195,7 +207,7 @@ func findTextContentMaxSize(width int, fontFile, content string) int {
 // 获取文字内容对应的width.
 //
 //nolint:gocritic
-func getTextContentXAndY(fontSize int, fontFile, content string) (int, int) {
+func getTextContentXAndY(fontSize int, measure textMeasure, content string) (int, int) {
 	// 延迟初始化
 	textContentCacheOnce.Do(func() {
 		textContentCache = &textContentXAndYCache{
@@ -205,7 +217,7 @@ func getTextContentXAndY(fontSize int, fontFile, content string) (int, int) {
 	})
 
 	// 计算cache key
-	key := pkg.GetStrMD5(fmt.Sprintf("%d%s%s", fontSize, fontFile, content))
+	key := pkg.GetStrMD5(fmt.Sprintf("%d%s%s", fontSize, measure.key(), content))
 	// 取数据
 	textContentCache.mtx.Lock()
 	width, xok := textContentCache.xCache[key]
@@ -215,7 +227,7 @@ func getTextContentXAndY(fontSize int, fontFile, content string) (int, int) {
 	if xok && yok {
 		return width, height
 	}
-	width, height = getTextContentSize(fontSize, fontFile, content)
+	width, height = getTextContentSize(fontSize, measure, content)
 
 	// 写入缓存
 	textContentCache.mtx.Lock()
@@ -237,7 +249,7 @@ func drawBorderLogo(fm *basePhotoFrame, logoImage image.Image, startX, startY, e
 	)
 }
 
-// 字符串颜色转RGBA.
+// 字符串颜色转RGBA,配置的颜色按照sRGB处理并转换到工作色彩空间.
 func strColor2RGBA(s string) color.RGBA {
 	if s == "" {
 		s = COLOR
@@ -248,7 +260,23 @@ func strColor2RGBA(s string) color.RGBA {
 	r2, _ := strconv.ParseUint(list[2], 10, 8)
 	r3, _ := strconv.ParseUint(list[3], 10, 8)
 
-	return color.RGBA{uint8(r0), uint8(r1), uint8(r2), uint8(r3)}
+	c := color.RGBA{uint8(r0), uint8(r1), uint8(r2), uint8(r3)}
+
+	return pkg.ConvertColorProfile(c, pkg.GetSRGBColorProfile(), internal.GetWorkingColorProfile())
+}
+
+// 字符串颜色转换为0xRRGGBBAA格式的整数,不做色彩空间转换,用于前端预览.
+func strColor2Int(s, defaultColor string) int {
+	if s == "" {
+		s = defaultColor
+	}
+	value := 0
+	for _, item := range strings.Split(s, ",") {
+		v, _ := strconv.ParseUint(strings.TrimSpace(item), 10, 8)
+		value = value<<8 | int(v)
+	}
+
+	return value
 }
 
 // 取绝对值.
@@ -294,49 +322,89 @@ func drawLine(img draw.Image, start, end image.Point, c color.Color) {
 	}
 }
 
-// 保存图片.
-func saveImageFile(saveImageFile string, image draw.Image, quality int) {
-	ext := filepath.Ext(saveImageFile)
-	if strings.EqualFold(ext, JPG_FILE_TYPE) || strings.EqualFold(ext, JPEG_FILE_TYPE) {
-		saveJpgImage(saveImageFile, image, quality)
+// 保存图片,按照导出选项选择编码器.
+func saveImageFile(saveImageFile string, image draw.Image, opt *layout.ExportOption) pkg.EError {
+	switch opt.GetFormat(saveImageFile) {
+	case layout.EXPORT_FORMAT_JPG:
+		quality := opt.JpegQuality
+		if quality == 0 {
+			quality = layout.EXPORT_DEFAULT_JPEG_QUALITY
+		}
 
-		return
+		return saveJpgImage(saveImageFile, image, quality)
+	case layout.EXPORT_FORMAT_TIFF:
+		return saveTiffImage(saveImageFile, image, opt.TiffCompression)
+	case layout.EXPORT_FORMAT_BMP:
+		return saveBmpImage(saveImageFile, image)
+	default:
+		return savePngImage(saveImageFile, image, opt.PngCompression)
 	}
-	savePngImage(saveImageFile, image)
 }
 
 // 保存JPG图片.
-func saveJpgImage(saveImageFile string, image draw.Image, quality int) {
-	file, err := os.Create(saveImageFile)
-	if err != nil {
-		internal.Log.Error(saveImageFile + ":图片打开失败:" + err.Error())
-		message.SendErrorMsg(saveImageFile + ":图片打开失败")
+func saveJpgImage(saveImageFile string, image draw.Image, quality int) pkg.EError {
+	profile := internal.GetWorkingColorProfile().Data
+
+	return writeImageFile(saveImageFile, func(w io.Writer) error {
+		return pkg.EncodeJpegWithColorProfile(w, image, quality, profile)
+	})
+}
 
-		return
+// 保存PNG图片.
+func savePngImage(saveImageFile string, image draw.Image, compression string) pkg.EError {
+	level := png.DefaultCompression
+	switch compression {
+	case layout.PNG_COMPRESSION_NONE:
+		level = png.NoCompression
+	case layout.PNG_COMPRESSION_SPEED:
+		level = png.BestSpeed
+	case layout.PNG_COMPRESSION_BEST:
+		level = png.BestCompression
 	}
-	defer file.Close()
+	profile := internal.GetWorkingColorProfile().Data
 
-	err = jpeg.Encode(file, image, &jpeg.Options{
-		Quality: quality,
+	return writeImageFile(saveImageFile, func(w io.Writer) error {
+		return pkg.EncodePngWithColorProfile(w, image, level, profile)
 	})
-	if err != nil {
-		internal.Log.Error(saveImageFile + ":图片写入失败:" + err.Error())
-		message.SendErrorMsg(saveImageFile + "图片写入失败:" + err.Error())
+}
+
+// 保存TIFF图片,默认使用deflate无损压缩.
+func saveTiffImage(saveImageFile string, image draw.Image, compression string) pkg.EError {
+	options := &tiff.Options{Compression: tiff.Deflate, Predictor: true}
+	if compression == layout.TIFF_COMPRESSION_NONE {
+		options = &tiff.Options{Compression: tiff.Uncompressed}
 	}
+
+	return writeImageFile(saveImageFile, func(w io.Writer) error {
+		return tiff.Encode(w, image, options)
+	})
 }
 
-// 保存PNG图片.
-func savePngImage(saveImageFile string, image draw.Image) {
+// 保存BMP图片.
+func saveBmpImage(saveImageFile string, image draw.Image) pkg.EError {
+	return writeImageFile(saveImageFile, func(w io.Writer) error {
+		return bmp.Encode(w, image)
+	})
+}
+
+// 创建图片文件并使用指定的编码器写入,写入失败时删除不完整的文件.
+func writeImageFile(saveImageFile string, encode func(w io.Writer) error) pkg.EError {
 	file, err := os.Create(saveImageFile)
 	if err != nil {
-		message.SendErrorMsg(saveImageFile + ":图片打开失败")
+		internal.Log.Error(saveImageFile + ":图片打开失败:" + err.Error())
 
-		return
+		return pkg.NewErrors(pkg.FILE_NOT_OPEN_ERROR, saveImageFile+":图片打开失败:"+err.Error())
+	}
+	err = encode(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
 	}
-	defer file.Close()
-
-	err = png.Encode(file, image)
 	if err != nil {
-		message.SendErrorMsg(saveImageFile + "图片写入失败:" + err.Error())
+		internal.Log.Error(saveImageFile + ":图片写入失败:" + err.Error())
+		os.Remove(saveImageFile)
+
+		return pkg.NewErrors(pkg.IMAGE_JPEG_SAVE_ERROR, saveImageFile+":图片写入失败:"+err.Error())
 	}
+
+	return pkg.NoError
 }
@@ -0,0 +1,266 @@
+package native
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"WaterMark/pkg"
+)
+
+// 圆角阴影参数.
+type roundedShadow struct {
+	color   color.RGBA
+	radius  int
+	sigma   int
+	offsetX int
+	offsetY int
+	opacity int
+}
+
+// 计算圆角矩形内指定像素的覆盖率(0~255),用于圆角抗锯齿.
+func roundedRectCoverage(x, y, width, height, radius int) uint8 {
+	if radius <= 0 {
+		return 255
+	}
+	// 计算像素所在的圆角圆心,不在四个角区域内的像素完全覆盖
+	cx, cy := -1.0, -1.0
+	switch {
+	case x < radius:
+		cx = float64(radius)
+	case x >= width-radius:
+		cx = float64(width - radius)
+	}
+	switch {
+	case y < radius:
+		cy = float64(radius)
+	case y >= height-radius:
+		cy = float64(height - radius)
+	}
+	if cx < 0 || cy < 0 {
+		return 255
+	}
+	// 像素中心到圆心的距离
+	d := math.Hypot(float64(x)+0.5-cx, float64(y)+0.5-cy)
+	coverage := float64(radius) - d + 0.5
+	if coverage <= 0 {
+		return 0
+	}
+	if coverage >= 1 {
+		return 255
+	}
+
+	return uint8(coverage * 255)
+}
+
+// 将照片以圆角的形式绘制到画布的指定位置.
+// 照片主体使用draw.Draw快速绘制,只对四个角区域逐像素计算抗锯齿混合.
+func drawRoundedImage(dst *image.RGBA, pt image.Point, src image.Image, radius int) {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	radius = min(radius, width/2, height/2)
+	if radius <= 0 {
+		draw.Draw(dst, bounds.Sub(bounds.Min).Add(pt), src, bounds.Min, draw.Over)
+
+		return
+	}
+	// 保存四个角区域原有的背景
+	corners := []image.Rectangle{
+		image.Rect(0, 0, radius, radius),
+		image.Rect(width-radius, 0, width, radius),
+		image.Rect(0, height-radius, radius, height),
+		image.Rect(width-radius, height-radius, width, height),
+	}
+	backups := make([]*image.RGBA, len(corners))
+	for i, corner := range corners {
+		backups[i] = image.NewRGBA(corner)
+		draw.Draw(backups[i], corner, dst, corner.Min.Add(pt), draw.Src)
+	}
+	draw.Draw(dst, bounds.Sub(bounds.Min).Add(pt), src, bounds.Min, draw.Over)
+
+	// 四个角区域按照覆盖率混合照片与背景
+	for i, corner := range corners {
+		for y := corner.Min.Y; y < corner.Max.Y; y++ {
+			for x := corner.Min.X; x < corner.Max.X; x++ {
+				a := uint32(roundedRectCoverage(x, y, width, height, radius))
+				if a == 255 {
+					continue
+				}
+				p := dst.RGBAAt(x+pt.X, y+pt.Y)
+				b := backups[i].RGBAAt(x, y)
+				dst.SetRGBA(x+pt.X, y+pt.Y, color.RGBA{
+					R: blendUint8(p.R, b.R, a),
+					G: blendUint8(p.G, b.G, a),
+					B: blendUint8(p.B, b.B, a),
+					A: blendUint8(p.A, b.A, a),
+				})
+			}
+		}
+	}
+}
+
+// 按照透明度混合两个颜色分量.
+func blendUint8(fg, bg uint8, a uint32) uint8 {
+	return uint8((uint32(fg)*a + uint32(bg)*(255-a) + 127) / 255)
+}
+
+// 在画布上绘制圆角矩形的高斯阴影,rect为圆角矩形(照片)所在的区域.
+func drawRoundedShadow(dst *image.RGBA, rect image.Rectangle, shadow roundedShadow) {
+	if shadow.sigma <= 0 || shadow.opacity <= 0 {
+		return
+	}
+	width, height := rect.Dx(), rect.Dy()
+	radius := min(shadow.radius, width/2, height/2)
+	// 阴影向四周扩散2个sigma的距离
+	pad := shadow.sigma * 2
+	shadowWidth, shadowHeight := width+pad*2, height+pad*2
+
+	alpha := make([]uint8, shadowWidth*shadowHeight)
+	pkg.ParallelRows(height, func(startRow, endRow int) {
+		for y := startRow; y < endRow; y++ {
+			row := (y + pad) * shadowWidth
+			for x := range width {
+				alpha[row+x+pad] = roundedRectCoverage(x, y, width, height, radius)
+			}
+		}
+	})
+	gaussianBlurAlpha(alpha, shadowWidth, shadowHeight, shadow.sigma)
+
+	origin := rect.Min.Add(image.Pt(shadow.offsetX-pad, shadow.offsetY-pad))
+	area := image.Rect(0, 0, shadowWidth, shadowHeight).Add(origin).Intersect(dst.Bounds())
+	opacity := uint32(min(shadow.opacity, 100))
+	pkg.ParallelRows(area.Dy(), func(startRow, endRow int) {
+		for y := area.Min.Y + startRow; y < area.Min.Y+endRow; y++ {
+			for x := area.Min.X; x < area.Max.X; x++ {
+				a := uint32(alpha[(y-origin.Y)*shadowWidth+x-origin.X]) * opacity / 100
+				if a == 0 {
+					continue
+				}
+				p := dst.RGBAAt(x, y)
+				dst.SetRGBA(x, y, color.RGBA{
+					R: blendUint8(shadow.color.R, p.R, a),
+					G: blendUint8(shadow.color.G, p.G, a),
+					B: blendUint8(shadow.color.B, p.B, a),
+					A: blendUint8(255, p.A, a),
+				})
+			}
+		}
+	})
+}
+
+// 在照片内侧绘制圆角描边,描边的外边缘与照片的圆角重合,rect为照片所在的区域.
+func drawRoundedStroke(dst *image.RGBA, rect image.Rectangle, radius, strokeWidth int, c color.RGBA) {
+	width, height := rect.Dx(), rect.Dy()
+	strokeWidth = min(strokeWidth, width/2, height/2)
+	if strokeWidth <= 0 || c.A == 0 {
+		return
+	}
+	radius = min(radius, width/2, height/2)
+	// 距离照片边缘超过band的像素不在描边范围内
+	band := strokeWidth + radius
+	pkg.ParallelRows(height, func(startRow, endRow int) {
+		for y := startRow; y < endRow; y++ {
+			isMiddleRow := y >= band && y < height-band && width > band*2
+			for x := 0; x < width; x++ {
+				// 中间的行只有左右两侧有描边,直接跳到右侧
+				if isMiddleRow && x == band {
+					x = width - band - 1
+
+					continue
+				}
+				a := uint32(roundedStrokeCoverage(x, y, width, height, radius, strokeWidth)) * uint32(c.A) / 255
+				if a == 0 {
+					continue
+				}
+				p := dst.RGBAAt(x+rect.Min.X, y+rect.Min.Y)
+				dst.SetRGBA(x+rect.Min.X, y+rect.Min.Y, color.RGBA{
+					R: blendUint8(c.R, p.R, a),
+					G: blendUint8(c.G, p.G, a),
+					B: blendUint8(c.B, p.B, a),
+					A: blendUint8(255, p.A, a),
+				})
+			}
+		}
+	})
+}
+
+// 计算圆角描边的覆盖率,即照片圆角矩形与向内收缩描边宽度之后的圆角矩形之间的区域.
+func roundedStrokeCoverage(x, y, width, height, radius, strokeWidth int) uint8 {
+	outer := roundedRectCoverage(x, y, width, height, radius)
+	if x < strokeWidth || y < strokeWidth || x >= width-strokeWidth || y >= height-strokeWidth {
+		return outer
+	}
+	inner := roundedRectCoverage(
+		x-strokeWidth, y-strokeWidth, width-strokeWidth*2, height-strokeWidth*2, max(radius-strokeWidth, 0),
+	)
+
+	return outer - min(inner, outer)
+}
+
+// 对单通道数据进行高斯模糊,使用3次盒式模糊近似,耗时与sigma大小无关.
+func gaussianBlurAlpha(alpha []uint8, width, height, sigma int) {
+	tmp := make([]uint8, len(alpha))
+	for _, box := range gaussianBoxSizes(float64(sigma), 3) {
+		r := (box - 1) / 2
+		boxBlurHorizontal(alpha, tmp, width, height, r)
+		boxBlurVertical(tmp, alpha, width, height, r)
+	}
+}
+
+// 计算近似高斯模糊需要的盒式模糊尺寸.
+func gaussianBoxSizes(sigma float64, n int) []int {
+	wIdeal := math.Sqrt(12*sigma*sigma/float64(n) + 1)
+	wl := int(math.Floor(wIdeal))
+	if wl%2 == 0 {
+		wl--
+	}
+	wu := wl + 2
+	mIdeal := (12*sigma*sigma - float64(n*wl*wl) - float64(4*n*wl) - float64(3*n)) / float64(-4*wl-4)
+	m := int(math.Round(mIdeal))
+
+	sizes := make([]int, n)
+	for i := range n {
+		sizes[i] = wu
+		if i < m {
+			sizes[i] = wl
+		}
+	}
+
+	return sizes
+}
+
+// 水平方向盒式模糊.
+func boxBlurHorizontal(src, dst []uint8, width, height, r int) {
+	size := 2*r + 1
+	pkg.ParallelRows(height, func(startRow, endRow int) {
+		for y := startRow; y < endRow; y++ {
+			row := y * width
+			sum := 0
+			for x := -r; x <= r; x++ {
+				sum += int(src[row+min(max(x, 0), width-1)])
+			}
+			for x := range width {
+				dst[row+x] = uint8((sum + size/2) / size)
+				sum += int(src[row+min(x+r+1, width-1)]) - int(src[row+max(x-r, 0)])
+			}
+		}
+	})
+}
+
+// 垂直方向盒式模糊.
+func boxBlurVertical(src, dst []uint8, width, height, r int) {
+	size := 2*r + 1
+	pkg.ParallelRows(width, func(startCol, endCol int) {
+		for x := startCol; x < endCol; x++ {
+			sum := 0
+			for y := -r; y <= r; y++ {
+				sum += int(src[min(max(y, 0), height-1)*width+x])
+			}
+			for y := range height {
+				dst[y*width+x] = uint8((sum + size/2) / size)
+				sum += int(src[min(y+r+1, height-1)*width+x]) - int(src[max(y-r, 0)*width+x])
+			}
+		}
+	})
+}
@@ -5,7 +5,7 @@ import (
 	"image/draw"
 	"sync"
 
-	"WaterMark/layout"
+	"WaterMark/message"
 	"WaterMark/pkg"
 )
 
@@ -17,8 +17,8 @@ type photoFrame struct {
 func (fm *photoFrame) drawMainImage(wg *sync.WaitGroup) {
 	defer wg.Done()
 
-	// 生成照片主体
-	if fm.opts.needSourceImage() {
+	// 生成照片主体,有圆角,阴影或者描边时在合并边框之后绘制
+	if fm.opts.needSourceImage() && !fm.hasPhotoStyle() {
 		draw.Draw(
 			fm.frameDraw,
 			fm.srcImage.imgDecode.Bounds().Add(image.Point{fm.borImage.leftWidth, fm.borImage.topHeight}),
@@ -43,16 +43,27 @@ func (fm *photoFrame) drawBorderImage(wg *sync.WaitGroup) pkg.EError {
 		image.Point{0, 0},
 		draw.Src,
 	)
+	// 按照背景的深浅调整文字颜色
+	fm.setTextContrastColor(fm.getBorderRects()[SIDE_BOTTOM])
 	// 相机的logo如果没有找到,则使用特定标识的logo进行代替
-	logo, logoErr := layout.GetLogoImageByNameAndWidhtAndHeight(
-		layout.GetLogoNameByMake(fm.opts.getMakeFromExif()),
+	logo, logoErr := fm.getContrastLogoImage(
+		fm.opts.getLogoName(),
 		fm.borImage.logoLay.layout.width,
 		fm.borImage.logoLay.layout.height,
+		fm.getBorderRects()[SIDE_BOTTOM],
 	)
 	if pkg.HasError(logoErr) {
 		return logoErr
 	}
 	fm.borImage.logoLay.item = logo
+	// 镜头logo加载失败时只是不展示镜头logo,不影响边框的绘制
+	if lensErr := fm.loadLensLogo(); pkg.HasError(lensErr) {
+		message.SendErrorMsg(lensErr.String())
+	}
+	// 二维码生成失败时,例如内容过长,只是不展示二维码
+	if qrErr := fm.loadQRCode(); pkg.HasError(qrErr) {
+		message.SendErrorMsg(qrErr.String())
+	}
 	simpleBorderFactory := &SimpleBorderFactory{}
 	simpleBorderFactory.createBorder(fm.opts.Params.Name).drawBorder(fm)
 
@@ -90,6 +101,47 @@ func (fm *photoFrame) drawMerge() draw.Image {
 		image.Pt(0, 0),
 		draw.Src,
 	)
+	// 阴影会延伸到下边框中,需要在合并下边框之后绘制
+	fm.drawStyledMainImage()
+	// 画照片上的水印
+	fm.drawOverlays(fm.frameDraw, fm.getPhotoRect())
+	// 画上,左,右边框中的文字与logo
+	fm.drawSideBands()
 
 	return fm.frameDraw
 }
+
+// 是否需要绘制照片的圆角,阴影或者描边.
+func (fm *photoFrame) hasPhotoStyle() bool {
+	borderRadius := fm.getBorderRadius()
+
+	return borderRadius > 0 || fm.getPhotoShadow(borderRadius).sigma > 0 || fm.getPhotoStrokeWidth() > 0
+}
+
+// 绘制带有圆角,阴影与描边的照片主体,只生成边框时只绘制阴影.
+func (fm *photoFrame) drawStyledMainImage() {
+	if !fm.hasPhotoStyle() {
+		return
+	}
+	canvas, ok := fm.frameDraw.(*image.RGBA)
+	if !ok {
+		canvas = image.NewRGBA(fm.frameDraw.Bounds())
+		draw.Draw(canvas, canvas.Bounds(), fm.frameDraw, fm.frameDraw.Bounds().Min, draw.Src)
+		fm.frameDraw = canvas
+	}
+	borderRadius := fm.getBorderRadius()
+	rect := fm.getPhotoRect()
+
+	drawRoundedShadow(canvas, rect, fm.getPhotoShadow(borderRadius))
+	if !fm.opts.needSourceImage() {
+		return
+	}
+	drawRoundedImage(canvas, rect.Min, fm.srcImage.imgDecode, borderRadius)
+	drawRoundedStroke(canvas, rect, borderRadius, fm.getPhotoStrokeWidth(), fm.getPhotoStrokeColor())
+}
+
+// 获取照片在最终图片中所在的区域.
+func (fm *photoFrame) getPhotoRect() image.Rectangle {
+	return image.Rect(0, 0, fm.srcImage.width, fm.srcImage.height).
+		Add(image.Pt(fm.borImage.leftWidth, fm.borImage.topHeight))
+}
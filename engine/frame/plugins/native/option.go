@@ -1,12 +1,14 @@
 package native
 
 import (
+	"image/color"
+	"strings"
+
 	"github.com/go-viper/mapstructure/v2"
 	"github.com/yijianlingcheng/go-exiftool"
 
 	"WaterMark/internal"
 	"WaterMark/layout"
-	"WaterMark/pkg"
 )
 
 type frameOption struct {
@@ -15,9 +17,14 @@ type frameOption struct {
 	SourceImageFile string                `mapstructure:"sourceImageFile"`
 	SaveImageFile   string                `mapstructure:"saveImageFile"`
 	Params          layout.FrameLayout    `mapstructure:"params"`
+	Export          layout.ExportOption   `mapstructure:"export"`
 	OriginWidth     int
 	OriginHeight    int
 	IsAutoSave      bool
+	// 相机与镜头名称转换为展示名称,并且按照GPS信息补充地名之后的exif信息,每张照片只转换一次.
+	displayExif exiftool.FileMetadata
+	// 模板使用旧版本的四个文字字段.
+	isLegacyTexts bool
 }
 
 // 是否需要加载原始图片.
@@ -25,8 +32,9 @@ func (fp *frameOption) needSourceImage() bool {
 	return fp.PhotoType != PHOTO_TYPE_BORDER
 }
 
+// 获取用于展示的exif信息.
 func (fp *frameOption) getExif() exiftool.FileMetadata {
-	return fp.Exif
+	return fp.displayExif
 }
 
 // 获取原始照片路径.
@@ -34,6 +42,11 @@ func (fp *frameOption) getSourceImageFile() string {
 	return fp.SourceImageFile
 }
 
+// 获取导出选项.
+func (fp *frameOption) getExportOption() *layout.ExportOption {
+	return &fp.Export
+}
+
 // 获取照片width.
 func (fp *frameOption) getSourceImageX() int {
 	width, widthIsOk := fp.Exif.Fields["ImageWidth"].(float64)
@@ -54,9 +67,36 @@ func (fp *frameOption) getSourceImageY() int {
 	return 0
 }
 
-// 获取照片对应的相机厂商.
-func (fp *frameOption) getMakeFromExif() string {
-	return pkg.AnyToString(fp.Exif.Fields["Make"])
+// 获取照片对应的logo名称.
+func (fp *frameOption) getLogoName() string {
+	return layout.GetLogoNameByExif(fp.Exif)
+}
+
+// 获取模板中svg格式logo的颜色,没有配置时保持logo原有的颜色.
+func (fp *frameOption) getLogoColor() color.Color {
+	if fp.Params.LogoColor == "" {
+		return nil
+	}
+
+	return strColor2RGBA(fp.Params.LogoColor)
+}
+
+// 获取照片对应的镜头厂商logo名称,模板没有开启镜头logo时返回不支持的logo.
+func (fp *frameOption) getLensLogoName() string {
+	if !fp.Params.LensLogo.Show {
+		return layout.UNSUPPORT_LOGO
+	}
+
+	return layout.GetLensLogoNameByExif(fp.Exif)
+}
+
+// 获取照片对应的二维码内容,没有配置或者exif中没有对应的值时返回空字符串.
+func (fp *frameOption) getQRCodeContent() string {
+	if fp.Params.QRCode.Content == "" {
+		return ""
+	}
+
+	return strings.TrimSpace(changeText2ExifContent(fp.getExif(), fp.Params.QRCode.Content))
 }
 
 // 获取一个结构体
@@ -67,6 +107,10 @@ func newFrameOption(opts map[string]any) *frameOption {
 	if err != nil {
 		internal.Log.Panic("opts转为frameOption失败:" + err.Error())
 	}
+	// 统一转换为文字列表,并且与传入的布局参数不再共用底层数组
+	fp.isLegacyTexts = len(fp.Params.Texts) == 0
+	fp.Params.Texts = fp.Params.GetTexts()
+	fp.displayExif = layout.AddPlaceFields(layout.NormalizeDisplayNames(fp.Exif))
 
 	return &fp
 }
@@ -75,12 +119,14 @@ func newFrameOption(opts map[string]any) *frameOption {
 func (fp *frameOption) resetSourceImageX(width int) {
 	fp.OriginWidth = fp.getSourceImageX()
 	fp.Exif.Fields["ImageWidth"] = float64(width)
+	fp.displayExif.Fields["ImageWidth"] = float64(width)
 }
 
 // 重置照片height.
 func (fp *frameOption) resetSourceImageY(height int) {
 	fp.OriginHeight = fp.getSourceImageY()
 	fp.Exif.Fields["ImageHeight"] = float64(height)
+	fp.displayExif.Fields["ImageHeight"] = float64(height)
 }
 
 // 是否是竖构图照片.
@@ -94,3 +140,24 @@ func (fp *frameOption) isVerticalImage() bool {
 
 	return fp.OriginWidth < fp.OriginHeight
 }
+
+// 获取指定位置的文字,模板中的文字数量不足时返回一个空文字.
+func (fp *frameOption) getText(index int) *layout.TextElement {
+	if index < len(fp.Params.Texts) {
+		return &fp.Params.Texts[index]
+	}
+
+	return &layout.TextElement{}
+}
+
+// 获取内容不为空的文字,居中布局中每个文字占一行.
+func (fp *frameOption) getTextLines() []*layout.TextElement {
+	lines := make([]*layout.TextElement, 0, len(fp.Params.Texts))
+	for i := range fp.Params.Texts {
+		if fp.Params.Texts[i].Content != "" {
+			lines = append(lines, &fp.Params.Texts[i])
+		}
+	}
+
+	return lines
+}
@@ -0,0 +1,227 @@
+package native
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"path/filepath"
+	"strconv"
+
+	"github.com/disintegration/imaging"
+
+	"WaterMark/internal"
+	"WaterMark/layout"
+	"WaterMark/message"
+	"WaterMark/pkg"
+)
+
+// 在照片区域中绘制水印,只生成边框时没有照片,不需要绘制.
+func (fm *basePhotoFrame) drawOverlays(dst draw.Image, rect image.Rectangle) {
+	if !fm.opts.needSourceImage() {
+		return
+	}
+	for i := range fm.opts.Params.Overlays {
+		overlay := &fm.opts.Params.Overlays[i]
+		img, err := fm.newOverlayImage(overlay, min(rect.Dx(), rect.Dy()))
+		if pkg.HasError(err) {
+			message.SendErrorMsg(err.String())
+
+			continue
+		}
+		if img == nil {
+			continue
+		}
+		for _, pt := range getOverlayPositions(rect, img.Bounds().Size(), overlay) {
+			drawOverlayImage(dst, rect, img, pt)
+		}
+	}
+}
+
+// 生成水印图片,图片在左,文字在右,两者垂直居中,然后按照不透明度与旋转角度处理.
+func (fm *basePhotoFrame) newOverlayImage(overlay *layout.Overlay, shortEdge int) (image.Image, pkg.EError) {
+	logo, err := loadOverlayLogo(overlay, shortEdge)
+	if pkg.HasError(err) {
+		return nil, err
+	}
+	textSize := shortEdge * getOverlayRatio(overlay.TextSize, OVERLAY_TEXT_SIZE) / 100
+	content := changeText2ExifContent(fm.opts.getExif(), overlay.Content)
+	fontFile, fallbacks := fm.getOverlayFont(overlay)
+	textWidth := 0
+	if content != "" && fontFile != "" {
+		textWidth, _ = getTextContentXAndY(textSize, newTextMeasure(fontFile, fallbacks), content)
+	}
+	logoSize := image.Point{}
+	gap := 0
+	if logo != nil {
+		logoSize = logo.Bounds().Size()
+		gap = min(textWidth, textSize/2)
+	}
+	canvas := image.NewRGBA(image.Rect(0, 0, logoSize.X+gap+textWidth, max(logoSize.Y, textSize*5/4)))
+	if canvas.Bounds().Empty() {
+		return nil, pkg.NoError
+	}
+	if logo != nil {
+		startY := (canvas.Bounds().Dy() - logoSize.Y) / 2
+		draw.Draw(canvas, image.Rect(0, startY, logoSize.X, startY+logoSize.Y), logo, logo.Bounds().Min, draw.Over)
+	}
+	if textWidth > 0 {
+		brush, brushErr := newTextBrush(fontFile, float64(textSize), &image.Uniform{strColor2RGBA(overlay.FontColor)},
+			fallbacks...)
+		if pkg.HasError(brushErr) {
+			return nil, brushErr
+		}
+		// 文字绘制时横向有10像素的偏移,可见部分位于起始坐标下方字体大小的1/4到5/4之间
+		startY := (canvas.Bounds().Dy()-textSize)/2 - textSize/4
+		if err = brush.drawFontOnRGBA(canvas, image.Pt(logoSize.X+gap-10, startY), content); pkg.HasError(err) {
+			return nil, err
+		}
+	}
+
+	return transformOverlayImage(canvas, overlay), pkg.NoError
+}
+
+// 获取水印的字体,没有指定字体文件时使用第一行文字的字体.
+func (fm *basePhotoFrame) getOverlayFont(overlay *layout.Overlay) (string, []string) {
+	if overlay.FontFile == "" {
+		text := fm.opts.getText(TEXT_ONE)
+
+		return text.FontFile, text.FallbackFonts
+	}
+	if len(overlay.FallbackFonts) > 0 {
+		return overlay.FontFile, overlay.FallbackFonts
+	}
+
+	return overlay.FontFile, fm.opts.Params.FallbackFonts
+}
+
+// 获取水印尺寸的百分比,没有配置时使用默认值.
+func getOverlayRatio(ratio, defaultRatio int) int {
+	if ratio > 0 {
+		return ratio
+	}
+	if defaultRatio > 0 {
+		return defaultRatio
+	}
+
+	return OVERLAY_TEXT_SIZE
+}
+
+// 加载水印图片,没有配置图片时返回nil,图片高度默认与文字大小相同.
+func loadOverlayLogo(overlay *layout.Overlay, shortEdge int) (image.Image, pkg.EError) {
+	if overlay.Image == "" {
+		return nil, pkg.NoError
+	}
+	height := shortEdge * getOverlayRatio(overlay.ImageSize, overlay.TextSize) / 100
+
+	return loadOverlayImage(overlay.Image, max(height, 1))
+}
+
+// 加载水印图片并按照高度缩放,完整路径直接读取图片文件,否则按照logo名称查找.
+func loadOverlayImage(name string, height int) (image.Image, pkg.EError) {
+	if !filepath.IsAbs(name) {
+		logo, err := layout.GetLogoImageByNameAndWidhtAndHeight(name, 0, height)
+		if pkg.HasError(err) {
+			return nil, err
+		}
+
+		return logo.LogoImage, pkg.NoError
+	}
+	img, err := internal.LoadImageWithWorkingColorSpace(name)
+	if pkg.HasError(err) {
+		return nil, err
+	}
+
+	return imaging.Resize(img, 0, height, imaging.Lanczos), pkg.NoError
+}
+
+// 按照不透明度与旋转角度处理水印图片,旋转之后空白的部分保持透明.
+func transformOverlayImage(img *image.RGBA, overlay *layout.Overlay) image.Image {
+	// 颜色为预乘透明度的格式,所有分量同时缩放
+	if overlay.Opacity > 0 && overlay.Opacity < 100 {
+		for i := range img.Pix {
+			img.Pix[i] = uint8(int(img.Pix[i]) * overlay.Opacity / 100)
+		}
+	}
+	if overlay.Rotation%360 == 0 {
+		return img
+	}
+
+	return imaging.Rotate(img, float64(overlay.Rotation), color.Transparent)
+}
+
+// 获取水印左上角在最终图片中的位置,重复时返回铺满照片区域需要的全部位置.
+func getOverlayPositions(rect image.Rectangle, size image.Point, overlay *layout.Overlay) []image.Point {
+	if overlay.Repeat == OVERLAY_REPEAT_TILE || overlay.Repeat == OVERLAY_REPEAT_DIAGONAL {
+		return getOverlayRepeatPositions(rect, size, overlay)
+	}
+
+	return []image.Point{getOverlayAnchorPosition(rect, size, overlay)}
+}
+
+// 按照九宫格位置与边距计算水印的位置,absolute时按照x与y计算水印中心的位置.
+func getOverlayAnchorPosition(rect image.Rectangle, size image.Point, overlay *layout.Overlay) image.Point {
+	if overlay.Anchor == OVERLAY_ANCHOR_ABSOLUTE {
+		return image.Pt(
+			rect.Min.X+rect.Dx()*overlay.X/100-size.X/2,
+			rect.Min.Y+rect.Dy()*overlay.Y/100-size.Y/2,
+		)
+	}
+	anchor := overlay.Anchor
+	if anchor == "" {
+		anchor = OVERLAY_ANCHOR_BOTTOM_RIGHT
+	}
+	margin := min(rect.Dx(), rect.Dy()) * overlay.Margin / 100
+	pt := image.Pt(rect.Min.X+(rect.Dx()-size.X)/2, rect.Min.Y+(rect.Dy()-size.Y)/2)
+	switch anchor {
+	case OVERLAY_ANCHOR_TOP_LEFT, OVERLAY_ANCHOR_LEFT, OVERLAY_ANCHOR_BOTTOM_LEFT:
+		pt.X = rect.Min.X + margin
+	case OVERLAY_ANCHOR_TOP_RIGHT, OVERLAY_ANCHOR_RIGHT, OVERLAY_ANCHOR_BOTTOM_RIGHT:
+		pt.X = rect.Max.X - margin - size.X
+	}
+	switch anchor {
+	case OVERLAY_ANCHOR_TOP_LEFT, OVERLAY_ANCHOR_TOP, OVERLAY_ANCHOR_TOP_RIGHT:
+		pt.Y = rect.Min.Y + margin
+	case OVERLAY_ANCHOR_BOTTOM_LEFT, OVERLAY_ANCHOR_BOTTOM, OVERLAY_ANCHOR_BOTTOM_RIGHT:
+		pt.Y = rect.Max.Y - margin - size.Y
+	}
+
+	return pt
+}
+
+// 平铺时从照片左上角开始按照间距排列,错位平铺时奇数行向左错开半个水印的距离.
+func getOverlayRepeatPositions(
+	rect image.Rectangle, size image.Point, overlay *layout.Overlay,
+) []image.Point {
+	shortEdge := min(rect.Dx(), rect.Dy())
+	spacing := shortEdge * getOverlayRatio(overlay.Spacing, OVERLAY_REPEAT_SPACING) / 100
+	stepX := max(size.X+spacing, 1)
+	stepY := max(size.Y+spacing, 1)
+	margin := shortEdge * overlay.Margin / 100
+	positions := make([]image.Point, 0)
+	for row, y := 0, rect.Min.Y+margin; y < rect.Max.Y; row, y = row+1, y+stepY {
+		startX := rect.Min.X + margin
+		if overlay.Repeat == OVERLAY_REPEAT_DIAGONAL && row%2 == 1 {
+			startX -= stepX / 2
+		}
+		for x := startX; x < rect.Max.X; x += stepX {
+			positions = append(positions, image.Pt(x, y))
+		}
+	}
+
+	return positions
+}
+
+// 在指定位置绘制水印,超出照片区域的部分不绘制.
+func drawOverlayImage(dst draw.Image, rect image.Rectangle, img image.Image, pt image.Point) {
+	target := img.Bounds().Sub(img.Bounds().Min).Add(pt)
+	clip := target.Intersect(rect)
+	if clip.Empty() {
+		return
+	}
+	draw.Draw(dst, clip, img, img.Bounds().Min.Add(clip.Min.Sub(pt)), draw.Over)
+}
+
+// 获取水印文字对应的字段名称.
+func getOverlayWordsName(index int) string {
+	return "overlays." + strconv.Itoa(index) + ".content"
+}
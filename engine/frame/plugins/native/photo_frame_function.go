@@ -31,10 +31,15 @@ func createBlurFrameImageRGBA(opts map[string]any) (draw.Image, pkg.EError) {
 	// 保存
 	imageFilePath := fm.getSaveImageFile()
 	if imageFilePath != "" {
-		saveImageFile(imageFilePath, finalImage, 100)
+		// 导出时嵌入隐形水印
+		finalImage = fm.embedInvisibleWatermark(finalImage, fm.getPhotoRect())
+		err = saveImageFile(imageFilePath, finalImage, fm.opts.getExportOption())
 	}
 	// 清理
 	fm.clean()
+	if pkg.HasError(err) {
+		return nil, err
+	}
 
 	return finalImage, pkg.NoError
 }
@@ -54,10 +59,15 @@ func createNormalFrameImageRGBA(opts map[string]any) (draw.Image, pkg.EError) {
 	// 保存
 	imageFilePath := fm.getSaveImageFile()
 	if imageFilePath != "" {
-		saveImageFile(imageFilePath, finalImage, 100)
+		// 导出时嵌入隐形水印
+		finalImage = fm.embedInvisibleWatermark(finalImage, fm.getPhotoRect())
+		err = saveImageFile(imageFilePath, finalImage, fm.opts.getExportOption())
 	}
 	// 清理
 	fm.clean()
+	if pkg.HasError(err) {
+		return nil, err
+	}
 
 	return finalImage, pkg.NoError
 }
@@ -83,6 +93,8 @@ func getBlurFrameImageBorderInfo(opts map[string]any) (map[string]any, pkg.EErro
 
 	info["size"] = fm.getFrameSize()
 	info["text"] = fm.getBorderText()
+	info["boxes"] = fm.getBorderBoxes()
+	info["lines"] = fm.getTextLineBoxes()
 
 	fm.clean()
 
@@ -100,6 +112,8 @@ func getNormalFrameImageBorderInfo(opts map[string]any) (map[string]any, pkg.EEr
 
 	info["size"] = fm.getFrameSize()
 	info["text"] = fm.getBorderText()
+	info["boxes"] = fm.getBorderBoxes()
+	info["lines"] = fm.getTextLineBoxes()
 
 	fm.clean()
 
@@ -21,6 +21,8 @@ type (
 	borderImage struct {
 		textLay      textMarks
 		logoLay      logoLayout
+		lensLogoLay  logoLayout
+		qrCodeLay    logoLayout
 		sepLay       separator
 		bgColor      color.RGBA
 		leftWidth    int
@@ -42,9 +44,13 @@ type (
 
 	// 文字水印.
 	textMark struct {
-		text   *textBrush
-		words  string
-		layout layoutBox
+		text       *textBrush
+		words      string
+		align      string
+		measure    textMeasure
+		layout     layoutBox
+		maxWidth   int
+		lineHeight int
 	}
 
 	separator struct {
@@ -76,17 +82,6 @@ type (
 	}
 )
 
-var (
-	// 文字位置一.
-	textPosOne = "one"
-	// 文字位置二.
-	textPosTwo = "two"
-	// 文字位置三.
-	textPosThree = "three"
-	// 文字位置四.
-	textPosFour = "four"
-)
-
 // 原始照片.
 func newSourceImage(path string) *sourceImage {
 	return &sourceImage{
@@ -107,44 +102,24 @@ func getBorderImage(fm baseFrame) (*borderImage, pkg.EError) {
 
 // 返回固定布局对象.
 func newBorderImage(params *layout.FrameLayout) (*borderImage, pkg.EError) {
-	brushErrs := make([]pkg.EError, 4)
-	oneTextBrush, oneErr := newTextBrush(
-		params.TextOneFontFile, float64(params.TextOneFontSize),
-		&image.Uniform{strColor2RGBA(params.TextOneFontColor)},
-	)
-	brushErrs[0] = oneErr
-	twoTextBrush, twoErr := newTextBrush(
-		params.TextTwoFontFile, float64(params.TextTwoFontSize),
-		&image.Uniform{strColor2RGBA(params.TextTwoFontColor)},
-	)
-	brushErrs[1] = twoErr
-	threeTextBrush, threeErr := newTextBrush(
-		params.TextThreeFontFile, float64(params.TextThreeFontSize),
-		&image.Uniform{strColor2RGBA(params.TextThreeFontColor)},
-	)
-	brushErrs[2] = threeErr
-	fourTextBrush, fourErr := newTextBrush(
-		params.TextFourFontFile, float64(params.TextFourFontSize),
-		&image.Uniform{strColor2RGBA(params.TextFourFontColor)},
-	)
-	brushErrs[3] = fourErr
-	for _, brushErr := range brushErrs {
-		if pkg.HasError(brushErr) {
-			return &borderImage{}, brushErr
-		}
+	list, brushErr := newTextMarks(params)
+	if pkg.HasError(brushErr) {
+		return &borderImage{}, brushErr
 	}
 
 	return &borderImage{
-			bgColor:      strColor2RGBA(params.BgColor),
+			bgColor:      templateColor2RGBA(params.BgColor, COLOR),
 			leftWidth:    params.MainMarginLeft,
 			rightWidth:   params.MainMarginRight,
 			topHeight:    params.MainMarginTop,
 			bottomHeight: params.MainMarginBottom,
 			textLay: textMarks{
-				list: newTextMarks(params, oneTextBrush, twoTextBrush, threeTextBrush, fourTextBrush),
+				list: list,
 			},
-			logoLay: logoLayout{item: &layout.Logo{}, layout: newLogoLayoutBox(params)},
-			sepLay:  newSeparator(params),
+			logoLay:     logoLayout{item: &layout.Logo{}, layout: newLogoLayoutBox(params)},
+			lensLogoLay: logoLayout{item: &layout.Logo{}, layout: newLensLogoLayoutBox(params)},
+			qrCodeLay:   logoLayout{item: &layout.Logo{}, layout: newQRCodeLayoutBox(params)},
+			sepLay:      newSeparator(params),
 		},
 		pkg.NoError
 }
@@ -161,7 +136,7 @@ func newSeparator(params *layout.FrameLayout) separator {
 			marginBottom: params.SeparatorMarginBottom,
 			marginLeft:   params.SeparatorMarginLeft,
 			marginRight:  params.SeparatorMarginRight,
-			color:        strColor2RGBA(params.SeparatorColor),
+			color:        templateColor2RGBA(params.SeparatorColor, SEPARATOR_COLOR),
 		}
 	}
 
@@ -171,67 +146,41 @@ func newSeparator(params *layout.FrameLayout) separator {
 }
 
 // 水印文字布局.
-func newTextMarks(
-	params *layout.FrameLayout,
-	oneTextBrush, twoTextBrush, threeTextBrush, fourTextBrush *textBrush,
-) []textMark {
-	return []textMark{
-		{
-			words:  params.TextOneContent,
-			text:   oneTextBrush,
-			layout: newTextLayout(params, textPosOne),
-		},
-		{
-			words:  params.TextTwoContent,
-			text:   twoTextBrush,
-			layout: newTextLayout(params, textPosTwo),
-		},
-		{
-			words:  params.TextThreeContent,
-			text:   threeTextBrush,
-			layout: newTextLayout(params, textPosThree),
-		},
-		{
-			words:  params.TextFourContent,
-			text:   fourTextBrush,
-			layout: newTextLayout(params, textPosFour),
-		},
-	}
+func newTextMarks(params *layout.FrameLayout) ([]textMark, pkg.EError) {
+	list := make([]textMark, 0, len(params.Texts))
+	for i := range params.Texts {
+		text := &params.Texts[i]
+		brush, brushErr := newTextBrush(
+			text.FontFile, float64(text.FontSize),
+			&image.Uniform{strColor2RGBA(text.FontColor)},
+			text.FallbackFonts...,
+		)
+		if pkg.HasError(brushErr) {
+			return nil, brushErr
+		}
+		brush.Effect = newTextEffect(text)
+		list = append(list, textMark{
+			words:      text.Content,
+			measure:    getTextMeasure(text),
+			align:      text.Align,
+			text:       brush,
+			layout:     newTextLayout(text),
+			maxWidth:   text.MaxWidth,
+			lineHeight: text.LineHeight,
+		})
+	}
+
+	return list, pkg.NoError
 }
 
 // 获取文字布局.
-func newTextLayout(params *layout.FrameLayout, pos string) layoutBox {
-	lay := layoutBox{
-		marginTop:    params.TextOneMarginTop,
-		marginBottom: params.TextOneMarginBottom,
-		marginLeft:   params.TextOneMarginLeft,
-		marginRight:  params.TextOneMarginRight,
-	}
-	switch pos {
-	case textPosTwo:
-		lay = layoutBox{
-			marginTop:    params.TextTwoMarginTop,
-			marginBottom: params.TextTwoMarginBottom,
-			marginLeft:   params.TextTwoMarginLeft,
-			marginRight:  params.TextTwoMarginRight,
-		}
-	case textPosThree:
-		lay = layoutBox{
-			marginTop:    params.TextThreeMarginTop,
-			marginBottom: params.TextThreeMarginBottom,
-			marginLeft:   params.TextThreeMarginLeft,
-			marginRight:  params.TextThreeMarginRight,
-		}
-	case textPosFour:
-		lay = layoutBox{
-			marginTop:    params.TextFourMarginTop,
-			marginBottom: params.TextFourMarginBottom,
-			marginLeft:   params.TextFourMarginLeft,
-			marginRight:  params.TextFourMarginRight,
-		}
+func newTextLayout(text *layout.TextElement) layoutBox {
+	return layoutBox{
+		marginTop:    text.MarginTop,
+		marginBottom: text.MarginBottom,
+		marginLeft:   text.MarginLeft,
+		marginRight:  text.MarginRight,
 	}
-
-	return lay
 }
 
 // logo布局.
@@ -267,3 +216,15 @@ func (src *sourceImage) SetImageXAndY(width, height int) {
 	src.width = width
 	src.height = height
 }
+
+// 根据对齐方式计算宽度为textWidth的文字区域在宽度为width的区域中的起始坐标.
+func (tm *textMark) getStartX(width, textWidth int) int {
+	if tm.align != layout.TEXT_ALIGN_CENTER && tm.align != layout.TEXT_ALIGN_RIGHT {
+		return tm.layout.marginLeft
+	}
+	if tm.align == layout.TEXT_ALIGN_RIGHT {
+		return width - tm.layout.marginRight - textWidth
+	}
+
+	return (width-textWidth)/2 + tm.layout.marginLeft - tm.layout.marginRight
+}
@@ -0,0 +1,253 @@
+package native
+
+import (
+	"image"
+	"image/draw"
+	"slices"
+	"strings"
+
+	"WaterMark/layout"
+	"WaterMark/pkg"
+)
+
+type (
+	// 多行文字中的一行,坐标为绘制文字时使用的坐标.
+	textLine struct {
+		words string
+		x     int
+		y     int
+		width int
+		// 两端对齐时分配到单词或者字符之间的宽度
+		extra int
+		// 是否是一段文字的最后一行,两端对齐时最后一行保持左对齐
+		isParagraphEnd bool
+	}
+
+	// 按照换行符与最大宽度拆分之后的多行文字.
+	textBlock struct {
+		lines    []textLine
+		width    int
+		fontSize int
+		lineStep int
+	}
+)
+
+// 拆分文字并计算文字区域的宽度,设置了最大宽度时使用最大宽度,否则使用最长一行的宽度.
+func newTextBlock(fontSize int, measure textMeasure, content string, maxWidth, lineHeight int) textBlock {
+	block := textBlock{
+		lines:    make([]textLine, 0, 1),
+		width:    maxWidth,
+		fontSize: fontSize,
+		lineStep: getTextLineStep(fontSize, lineHeight),
+	}
+	for _, paragraph := range splitTextParagraphs(content) {
+		words := wrapTextParagraph(fontSize, measure, paragraph, maxWidth)
+		for i, word := range words {
+			width, _ := getTextContentXAndY(fontSize, measure, word)
+			block.lines = append(block.lines, textLine{words: word, width: width, isParagraphEnd: i == len(words)-1})
+		}
+	}
+	if maxWidth <= 0 {
+		for _, line := range block.lines {
+			block.width = max(block.width, line.width)
+		}
+	}
+
+	return block
+}
+
+// 获取文字元素对应的多行文字.
+func newTextElementBlock(text *layout.TextElement, content string) textBlock {
+	return newTextBlock(text.FontSize, getTextMeasure(text), content, text.MaxWidth, text.LineHeight)
+}
+
+// 相邻两行文字之间的距离.
+func getTextLineStep(fontSize, lineHeight int) int {
+	if lineHeight <= 0 {
+		lineHeight = TEXT_LINE_HEIGHT
+	}
+
+	return fontSize * lineHeight / 100
+}
+
+// 按照换行符拆分段落.
+func splitTextParagraphs(content string) []string {
+	content = strings.ReplaceAll(content, TEXT_LINE_BREAK_ESCAPE, TEXT_LINE_BREAK)
+	paragraphs := strings.Split(content, TEXT_LINE_BREAK)
+	for i := range paragraphs {
+		paragraphs[i] = strings.TrimSuffix(paragraphs[i], "\r")
+	}
+
+	return paragraphs
+}
+
+// 按照最大宽度拆分一段文字,优先在空格处换行,单个单词超出宽度时按照字符拆分.
+func wrapTextParagraph(fontSize int, measure textMeasure, paragraph string, maxWidth int) []string {
+	if maxWidth <= 0 {
+		return []string{paragraph}
+	}
+	if width, _ := getTextContentXAndY(fontSize, measure, paragraph); width <= maxWidth {
+		return []string{paragraph}
+	}
+	lines := make([]string, 0, 2)
+	current := ""
+	for _, word := range strings.Fields(paragraph) {
+		candidate := word
+		if current != "" {
+			candidate = current + " " + word
+		}
+		if width, _ := getTextContentXAndY(fontSize, measure, candidate); width <= maxWidth {
+			current = candidate
+
+			continue
+		}
+		if current != "" {
+			lines = append(lines, current)
+		}
+		pieces := splitTextByWidth(fontSize, measure, word, maxWidth)
+		lines = append(lines, pieces[:len(pieces)-1]...)
+		current = pieces[len(pieces)-1]
+	}
+
+	return append(lines, current)
+}
+
+// 按照字符拆分超出最大宽度的文字,每段至少包含一个字符.
+func splitTextByWidth(fontSize int, measure textMeasure, word string, maxWidth int) []string {
+	pieces := make([]string, 0, 1)
+	current := ""
+	for _, r := range word {
+		candidate := current + string(r)
+		if width, _ := getTextContentXAndY(fontSize, measure, candidate); width > maxWidth && current != "" {
+			pieces = append(pieces, current)
+			candidate = string(r)
+		}
+		current = candidate
+	}
+
+	return append(pieces, current)
+}
+
+// 文字区域的高度,单行文字时与字体大小相同.
+func (block *textBlock) getHeight() int {
+	return block.fontSize + block.lineStep*max(len(block.lines)-1, 0)
+}
+
+// 按照对齐方式计算每一行的坐标,startX与startY为文字区域的起始坐标.
+func (block *textBlock) getLines(align string, startX, startY int) []textLine {
+	lines := slices.Clone(block.lines)
+	for i := range lines {
+		line := &lines[i]
+		line.x = startX
+		line.y = startY + block.lineStep*i
+		switch align {
+		case layout.TEXT_ALIGN_CENTER:
+			line.x += (block.width - line.width) / 2
+		case layout.TEXT_ALIGN_RIGHT:
+			line.x += block.width - line.width
+		case layout.TEXT_ALIGN_JUSTIFY:
+			if !line.isParagraphEnd {
+				line.extra = max(block.width-line.width, 0)
+			}
+		}
+	}
+
+	return lines
+}
+
+// 获取文字水印对应的多行文字.
+func (tm *textMark) newTextBlock(content string) textBlock {
+	return newTextBlock(int(tm.text.FontSize), tm.measure, content, tm.maxWidth, tm.lineHeight)
+}
+
+// 获取文字水印在宽度为width的区域中每一行的坐标.
+func (tm *textMark) getLines(width int, content string) []textLine {
+	block := tm.newTextBlock(content)
+
+	return block.getLines(tm.align, tm.getStartX(width, block.width), tm.layout.marginTop)
+}
+
+// 绘制多行文字,offset为文字区域在目标图片中的偏移.
+func (tm *textMark) drawLines(dst draw.Image, lines []textLine, offset image.Point) pkg.EError {
+	for _, line := range lines {
+		line.x += offset.X
+		line.y += offset.Y
+		if err := tm.drawLine(dst, line); pkg.HasError(err) {
+			return err
+		}
+	}
+
+	return pkg.NoError
+}
+
+// 绘制一行文字,两端对齐时按照单词或者字符分别绘制.
+func (tm *textMark) drawLine(dst draw.Image, line textLine) pkg.EError {
+	units, separator := splitJustifyUnits(line.words)
+	if line.extra == 0 || len(units) < 2 {
+		return tm.text.drawFontOnRGBA(dst, image.Pt(line.x, line.y), line.words)
+	}
+	gaps := len(units) - 1
+	for i, unit := range units {
+		x := line.x + line.extra*i/gaps
+		if i > 0 {
+			x += tm.getPrefixWidth(strings.Join(units[:i], separator) + separator)
+		}
+		if err := tm.text.drawFontOnRGBA(dst, image.Pt(x, line.y), unit); pkg.HasError(err) {
+			return err
+		}
+	}
+
+	return pkg.NoError
+}
+
+// 两端对齐时有空格的文字按照单词拆分,没有空格的文字按照字符拆分.
+func splitJustifyUnits(words string) ([]string, string) {
+	if strings.Contains(words, " ") {
+		return strings.Split(words, " "), " "
+	}
+
+	return strings.Split(words, ""), ""
+}
+
+// 计算一行文字中前一部分的宽度,即下一部分文字的起始位置,需要去掉测量时包含的描边宽度.
+func (tm *textMark) getPrefixWidth(prefix string) int {
+	width, _ := getTextContentXAndY(int(tm.text.FontSize), tm.measure, prefix)
+
+	return width - tm.text.Effect.strokeWidth*2 + tm.text.Effect.letterSpacing
+}
+
+// 文字绘制时横向有10像素的偏移,纵向按照基线上方字体大小的3/4与下方的1/4计算.
+func (tm *textMark) getLineRect(line textLine) image.Rectangle {
+	fontSize := int(tm.text.FontSize)
+	x := line.x + 10
+	y := line.y + fontSize/4
+
+	return image.Rect(x, y, x+line.width+line.extra, y+fontSize)
+}
+
+// 获取边框文字每一行在最终图片中的区域.
+func (fm *basePhotoFrame) getTextLineBoxes() []map[string]any {
+	boxes := make([]map[string]any, 0, len(fm.borImage.textLay.list))
+	origin := fm.getBorderRects()[SIDE_BOTTOM].Min.Add(image.Pt(fm.borImage.leftWidth, 0))
+	for i := range fm.borImage.textLay.list {
+		textMark := &fm.borImage.textLay.list[i]
+		if textMark.words == "" {
+			continue
+		}
+		content := changeText2ExifContent(fm.opts.getExif(), textMark.words)
+		for index, line := range textMark.getLines(fm.srcImage.width, content) {
+			rect := textMark.getLineRect(line).Add(origin)
+			boxes = append(boxes, map[string]any{
+				"name":   fm.getTextWordsName(i),
+				"index":  index,
+				"text":   line.words,
+				"x":      rect.Min.X,
+				"y":      rect.Min.Y,
+				"width":  rect.Dx(),
+				"height": rect.Dy(),
+			})
+		}
+	}
+
+	return boxes
+}
@@ -0,0 +1,258 @@
+package native
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/disintegration/imaging"
+	"github.com/golang/freetype"
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+
+	"WaterMark/layout"
+	"WaterMark/pkg"
+)
+
+// 文字效果,尺寸已经按照字体大小换算为像素.
+type textEffect struct {
+	strokeColor   color.Color
+	shadowColor   color.Color
+	strokeWidth   int
+	shadowOffsetX int
+	shadowOffsetY int
+	shadowBlur    int
+	letterSpacing int
+	opacity       int
+}
+
+// 根据文字元素的配置生成文字效果.
+func newTextEffect(text *layout.TextElement) textEffect {
+	effect := textEffect{
+		strokeWidth:   getTextEffectPixel(text.FontSize, text.StrokeWidth),
+		shadowOffsetX: text.FontSize * text.ShadowOffsetX / 100,
+		shadowOffsetY: text.FontSize * text.ShadowOffsetY / 100,
+		shadowBlur:    text.FontSize * text.ShadowBlur / 100,
+		letterSpacing: text.FontSize * text.LetterSpacing / 100,
+		opacity:       text.Opacity,
+	}
+	if effect.strokeWidth > 0 {
+		effect.strokeColor = color.RGBA{A: 255}
+		if text.StrokeColor != "" {
+			effect.strokeColor = strColor2RGBA(text.StrokeColor)
+		}
+	}
+	if text.ShadowColor != "" {
+		effect.shadowColor = strColor2RGBA(text.ShadowColor)
+	}
+
+	return effect
+}
+
+// 百分比换算为像素,配置了效果时至少为1像素.
+func getTextEffectPixel(fontSize, ratio int) int {
+	if ratio <= 0 {
+		return 0
+	}
+
+	return max(1, fontSize*ratio/100)
+}
+
+// 是否没有任何文字效果.
+func (e *textEffect) isEmpty() bool {
+	return e.strokeWidth == 0 && e.shadowColor == nil && e.letterSpacing == 0 && (e.opacity <= 0 || e.opacity >= 100)
+}
+
+// 获取不透明度对应的颜色.
+func (e *textEffect) applyOpacity(c color.Color) color.Color {
+	if e.opacity <= 0 || e.opacity >= 100 {
+		return c
+	}
+	r, g, b, a := c.RGBA()
+	scale := func(v uint32) uint16 {
+		return uint16(v * uint32(e.opacity) / 100)
+	}
+
+	return color.RGBA64{R: scale(r), G: scale(g), B: scale(b), A: scale(a)}
+}
+
+// 测量参数中是否包含会改变文字宽度的效果.
+func (m textMeasure) hasEffect() bool {
+	return m.letterSpacing != 0 || m.strokeWidth > 0
+}
+
+// 按照测量的字体大小计算字间距与描边增加的宽度.
+func (m textMeasure) getEffectWidth(fontSize int, content string) int {
+	return getTextEffectWidth(content, fontSize*m.letterSpacing/100, getTextEffectPixel(fontSize, m.strokeWidth))
+}
+
+// 字间距与描边增加的宽度.
+func getTextEffectWidth(content string, letterSpacing, strokeWidth int) int {
+	return letterSpacing*max(utf8.RuneCountInString(content)-1, 0) + strokeWidth*2
+}
+
+// 绘制带效果的文字,先在文字区域的透明图层中绘制字形,再依次合成阴影,描边与文字.
+func (fb *textBrush) drawEffectFontOnRGBA(rgba draw.Image, pt image.Point, content string) pkg.EError {
+	effect := &fb.Effect
+	fonts := append([]*truetype.Font{fb.FontType}, fb.Fallbacks...)
+	fontSize := int(fb.FontSize)
+	pad := effect.strokeWidth + effect.shadowBlur*3 + max(abs(effect.shadowOffsetX), abs(effect.shadowOffsetY))
+	width := fb.measureRuns(fonts, content) + getTextEffectWidth(content, effect.letterSpacing, effect.strokeWidth)
+	glyph := image.NewAlpha(image.Rect(0, 0, max(width, 0)+fontSize/2+pad*2, fontSize*3/2+pad*2))
+	if err := fb.drawGlyphMask(glyph, fonts, image.Pt(pad+effect.strokeWidth, pad), content); pkg.HasError(err) {
+		return err
+	}
+	// 图层左上角在目标图片中的位置,与不使用效果时的文字位置保持一致
+	origin := image.Pt(pt.X+10-pad, pt.Y-pad)
+	outline := glyph
+	if effect.strokeWidth > 0 {
+		outline = dilateAlphaMask(glyph, effect.strokeWidth)
+	}
+	if effect.shadowColor != nil {
+		var shadow image.Image = outline
+		if effect.shadowBlur > 0 {
+			shadow = imaging.Blur(outline, float64(effect.shadowBlur))
+		}
+		shadowOrigin := origin.Add(image.Pt(effect.shadowOffsetX, effect.shadowOffsetY))
+		drawTextEffectMask(rgba, shadow, shadowOrigin, effect.applyOpacity(effect.shadowColor))
+	}
+	if effect.strokeWidth > 0 {
+		drawTextEffectMask(rgba, outline, origin, effect.applyOpacity(effect.strokeColor))
+	}
+	drawTextEffectMask(rgba, glyph, origin, effect.applyOpacity(fb.FontColor.C))
+
+	return pkg.NoError
+}
+
+// 计算文字不包含效果时的宽度.
+func (fb *textBrush) measureRuns(fonts []*truetype.Font, content string) int {
+	var width fixed.Int26_6
+	for _, run := range splitFontRuns(fonts, content) {
+		face := truetype.NewFace(run.font, &truetype.Options{Size: fb.FontSize})
+		width += font.MeasureString(face, run.text)
+	}
+
+	return width.Ceil()
+}
+
+// 在透明图层中绘制字形,start为文字左上角,有字间距时逐个字符绘制.
+func (fb *textBrush) drawGlyphMask(
+	mask *image.Alpha, fonts []*truetype.Font, start image.Point, content string,
+) pkg.EError {
+	c := freetype.NewContext()
+	c.SetDPI(72)
+	c.SetHinting(font.HintingFull)
+	c.SetFontSize(fb.FontSize)
+	c.SetClip(mask.Bounds())
+	c.SetDst(mask)
+	c.SetSrc(image.Opaque)
+	next := freetype.Pt(start.X, start.Y+int(c.PointToFixed(fb.FontSize)>>6))
+	spacing := fixed.I(fb.Effect.letterSpacing)
+	for _, run := range splitFontRuns(fonts, content) {
+		c.SetFont(run.font)
+		for _, word := range splitTextEffectWords(run.text, spacing != 0) {
+			end, err := c.DrawString(word, next)
+			if err != nil {
+				return pkg.NewErrors(pkg.IMAGE_TEXT_DRAW_TXT_ERROR, content+":绘制失败,原因:"+err.Error())
+			}
+			next = end
+			next.X += spacing
+		}
+	}
+
+	return pkg.NoError
+}
+
+// 有字间距时按照字符拆分,没有字间距时整段绘制保留字距调整.
+func splitTextEffectWords(text string, hasSpacing bool) []string {
+	if !hasSpacing {
+		return []string{text}
+	}
+
+	return strings.Split(text, "")
+}
+
+// 使用图层的透明度作为蒙版,把颜色绘制到目标图片中.
+func drawTextEffectMask(dst draw.Image, mask image.Image, origin image.Point, c color.Color) {
+	bounds := mask.Bounds()
+	draw.DrawMask(dst, bounds.Add(origin).Sub(bounds.Min), &image.Uniform{c}, image.Point{}, mask, bounds.Min, draw.Over)
+}
+
+// 描边:计算每个像素到字形的距离,距离小于描边宽度的像素作为描边,边缘按照距离抗锯齿.
+func dilateAlphaMask(mask *image.Alpha, radius int) *image.Alpha {
+	bounds := mask.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	grid := make([]float64, w*h)
+	for y := range h {
+		for x := range w {
+			grid[y*w+x] = math.MaxFloat32
+			if mask.Pix[y*mask.Stride+x] >= 128 {
+				grid[y*w+x] = 0
+			}
+		}
+	}
+	distanceTransform2D(grid, w, h)
+	out := image.NewAlpha(bounds)
+	for y := range h {
+		for x := range w {
+			alpha := min(max(float64(radius)+0.5-math.Sqrt(grid[y*w+x]), 0), 1) * 255
+			out.Pix[y*out.Stride+x] = max(uint8(alpha), mask.Pix[y*mask.Stride+x])
+		}
+	}
+
+	return out
+}
+
+// 二维欧氏距离平方变换,按照行和列分别进行一维变换.
+func distanceTransform2D(grid []float64, w, h int) {
+	size := max(w, h)
+	f := make([]float64, size)
+	d := make([]float64, size)
+	v := make([]int, size)
+	z := make([]float64, size+1)
+	for x := range w {
+		for y := range h {
+			f[y] = grid[y*w+x]
+		}
+		distanceTransform1D(f[:h], d, v, z)
+		for y := range h {
+			grid[y*w+x] = d[y]
+		}
+	}
+	for y := range h {
+		copy(f, grid[y*w:(y+1)*w])
+		distanceTransform1D(f[:w], d, v, z)
+		copy(grid[y*w:(y+1)*w], d[:w])
+	}
+}
+
+// 一维欧氏距离平方变换(Felzenszwalb算法),结果写入d.
+func distanceTransform1D(f, d []float64, v []int, z []float64) {
+	n := len(f)
+	k := 0
+	v[0] = 0
+	z[0] = -math.MaxFloat64
+	z[1] = math.MaxFloat64
+	for q := 1; q < n; q++ {
+		s := ((f[q] + float64(q*q)) - (f[v[k]] + float64(v[k]*v[k]))) / float64(2*q-2*v[k])
+		for s <= z[k] {
+			k--
+			s = ((f[q] + float64(q*q)) - (f[v[k]] + float64(v[k]*v[k]))) / float64(2*q-2*v[k])
+		}
+		k++
+		v[k] = q
+		z[k] = s
+		z[k+1] = math.MaxFloat64
+	}
+	k = 0
+	for q := range n {
+		for z[k+1] < float64(q) {
+			k++
+		}
+		d[q] = float64((q-v[k])*(q-v[k])) + f[v[k]]
+	}
+}
@@ -15,6 +15,11 @@ var textFontCache sync.Map
 
 // 带缓存的加载字体文件.
 func loadTextFontWithCache(fontFilePath string) (*truetype.Font, pkg.EError) {
+	return loadTextFontFileWithCache(internal.GetFontFilePath(fontFilePath))
+}
+
+// 带缓存的加载完整路径的字体文件.
+func loadTextFontFileWithCache(fontFilePath string) (*truetype.Font, pkg.EError) {
 	// 计算md5
 	md5 := pkg.GetStrMD5(fontFilePath)
 
@@ -26,7 +31,6 @@ func loadTextFontWithCache(fontFilePath string) (*truetype.Font, pkg.EError) {
 		internal.Log.Error(pkg.ImageTextCacheTypeError.String())
 	}
 
-	fontFilePath = internal.GetFontFilePath(fontFilePath)
 	fontFile, err := os.ReadFile(fontFilePath)
 	if err != nil {
 		errMsg := fontFilePath + ":字体文件读取失败:" + err.Error()
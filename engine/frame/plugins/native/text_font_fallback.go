@@ -0,0 +1,121 @@
+package native
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+
+	"WaterMark/internal"
+	"WaterMark/layout"
+	"WaterMark/message"
+	"WaterMark/pkg"
+)
+
+type (
+	// 使用同一个字体绘制的一段文字.
+	fontRun struct {
+		font *truetype.Font
+		text string
+	}
+
+	// 测量文字宽高使用的参数,字体链中第一个字体为主字体,之后为备用字体.
+	textMeasure struct {
+		fontPaths []string
+		// 字间距与描边会改变文字的宽度,保存百分比,测量时按照字体大小换算为像素
+		letterSpacing int
+		strokeWidth   int
+	}
+)
+
+// 获取字体链对应的测量参数,没有备用字体时只包含主字体.
+func newTextMeasure(fontFile string, fallbacks []string) textMeasure {
+	measure := textMeasure{fontPaths: []string{internal.GetFontFilePath(fontFile)}}
+	// 没有主字体时不绘制文字,备用字体也不需要
+	if fontFile == "" {
+		return measure
+	}
+	for _, fallback := range fallbacks {
+		if fallback != "" && fallback != fontFile {
+			measure.fontPaths = append(measure.fontPaths, internal.GetFontFilePath(fallback))
+		}
+	}
+
+	return measure
+}
+
+// 获取文字元素的测量参数.
+func getTextMeasure(text *layout.TextElement) textMeasure {
+	measure := newTextMeasure(text.FontFile, text.FallbackFonts)
+	measure.letterSpacing = text.LetterSpacing
+	measure.strokeWidth = max(text.StrokeWidth, 0)
+
+	return measure
+}
+
+// 获取测量结果缓存使用的key.
+func (m textMeasure) key() string {
+	return fmt.Sprintf("%s\n%d,%d", strings.Join(m.fontPaths, "\n"), m.letterSpacing, m.strokeWidth)
+}
+
+// 是否包含备用字体.
+func (m textMeasure) hasFallbacks() bool {
+	return len(m.fontPaths) > 1
+}
+
+// 加载字体链中的全部字体.
+func (m textMeasure) loadFonts() ([]*truetype.Font, pkg.EError) {
+	fonts := make([]*truetype.Font, 0, len(m.fontPaths))
+	for _, path := range m.fontPaths {
+		fontType, err := loadTextFontFileWithCache(path)
+		if pkg.HasError(err) {
+			return nil, err
+		}
+		fonts = append(fonts, fontType)
+	}
+
+	return fonts, pkg.NoError
+}
+
+// 按照字体中是否有字符对应的字形将文字拆分为多段
+// 字符优先使用靠前的字体,所有字体都没有对应字形时使用主字体.
+func splitFontRuns(fonts []*truetype.Font, content string) []fontRun {
+	runs := make([]fontRun, 0, 1)
+	for _, r := range content {
+		runFont := fonts[0]
+		for _, item := range fonts {
+			if item.Index(r) != 0 {
+				runFont = item
+
+				break
+			}
+		}
+		if n := len(runs); n > 0 && runs[n-1].font == runFont {
+			runs[n-1].text += string(r)
+
+			continue
+		}
+		runs = append(runs, fontRun{font: runFont, text: string(r)})
+	}
+
+	return runs
+}
+
+// 计算使用字体链绘制的文字宽高,每段文字使用各自的字体测量,高度与gg库的计算方式保持一致.
+func getFontChainContentSize(fontSize int, measure textMeasure, content string) (int, int) {
+	fonts, err := measure.loadFonts()
+	if pkg.HasError(err) {
+		message.SendErrorMsg(err.String())
+
+		return 0, 0
+	}
+	var width fixed.Int26_6
+	for _, run := range splitFontRuns(fonts, content) {
+		face := truetype.NewFace(run.font, &truetype.Options{Size: float64(fontSize)})
+		width += font.MeasureString(face, run.text)
+	}
+
+	return int(width >> 6), fontSize * 72 / 96
+}
@@ -15,6 +15,8 @@ import (
 type textBrush struct {
 	FontType  *truetype.Font
 	FontColor *image.Uniform
+	Fallbacks []*truetype.Font
+	Effect    textEffect
 	FontSize  float64
 }
 
@@ -23,7 +25,10 @@ type textBrush struct {
 //	fontFilePath 字体文件路径
 //	fontSize 字体size
 //	fontColor 字体颜色
-func newTextBrush(fontFilePath string, fontSize float64, fontColor *image.Uniform) (*textBrush, pkg.EError) {
+//	fallbacks 备用字体文件
+func newTextBrush(
+	fontFilePath string, fontSize float64, fontColor *image.Uniform, fallbacks ...string,
+) (*textBrush, pkg.EError) {
 	if fontFilePath == "" {
 		return &textBrush{}, pkg.NoError
 	}
@@ -33,7 +38,19 @@ func newTextBrush(fontFilePath string, fontSize float64, fontColor *image.Unifor
 		return nil, err
 	}
 
-	return &textBrush{FontType: fontType, FontSize: fontSize, FontColor: fontColor}, pkg.NoError
+	brush := &textBrush{FontType: fontType, FontSize: fontSize, FontColor: fontColor}
+	for _, fallback := range fallbacks {
+		if fallback == "" || fallback == fontFilePath {
+			continue
+		}
+		fallbackType, fallbackErr := loadTextFontWithCache(fallback)
+		if pkg.HasError(fallbackErr) {
+			return nil, fallbackErr
+		}
+		brush.Fallbacks = append(brush.Fallbacks, fallbackType)
+	}
+
+	return brush, pkg.NoError
 }
 
 // drawFontOnRGBA 图片插入文字
@@ -45,6 +62,9 @@ func (fb *textBrush) drawFontOnRGBA(rgba draw.Image, pt image.Point, content str
 	if fb.FontType == nil {
 		return pkg.NoError
 	}
+	if !fb.Effect.isEmpty() {
+		return fb.drawEffectFontOnRGBA(rgba, pt, content)
+	}
 	c := freetype.NewContext()
 	c.SetDPI(72)
 	c.SetFont(fb.FontType)
@@ -53,9 +73,16 @@ func (fb *textBrush) drawFontOnRGBA(rgba draw.Image, pt image.Point, content str
 	c.SetClip(rgba.Bounds())
 	c.SetDst(rgba)
 	c.SetSrc(fb.FontColor)
-	_, err := c.DrawString(content, freetype.Pt(pt.X+10, pt.Y+int(c.PointToFixed(fb.FontSize)>>6)))
-	if err != nil {
-		return pkg.NewErrors(pkg.IMAGE_TEXT_DRAW_TXT_ERROR, content+":绘制失败,原因:"+err.Error())
+	start := freetype.Pt(pt.X+10, pt.Y+int(c.PointToFixed(fb.FontSize)>>6))
+	// 按照字形拆分为多段,依次使用对应的字体绘制,下一段从上一段结束的位置开始
+	fonts := append([]*truetype.Font{fb.FontType}, fb.Fallbacks...)
+	for _, run := range splitFontRuns(fonts, content) {
+		c.SetFont(run.font)
+		next, err := c.DrawString(run.text, start)
+		if err != nil {
+			return pkg.NewErrors(pkg.IMAGE_TEXT_DRAW_TXT_ERROR, content+":绘制失败,原因:"+err.Error())
+		}
+		start = next
 	}
 
 	return pkg.NoError
@@ -7,31 +7,156 @@ const (
 	// 默认颜色.
 	COLOR = "255,255,255,255"
 
+	// 照片阴影默认颜色,与ImageMagick中的grey一致.
+	BLUR_SHADOW_COLOR = "190,190,190,255"
+
+	// 照片阴影默认不透明度.
+	BLUR_SHADOW_OPACITY = 50
+
+	// 照片内描边默认颜色.
+	PHOTO_STROKE_COLOR = "255,255,255,255"
+
 	// 使用gps或者时间,gps信息不存在则使用时间.
 	GPS_OR_DATETIME = "GPS_OR_DATETIME"
 
+	// 使用城市,没有找到城市时与GPS_OR_DATETIME一致.
+	CITY_OR_GPS = "CITY_OR_GPS"
+
 	// 原始时间.
 	DATE_TIME_ORIGINAL = "DateTimeOriginal"
 
 	// gps信息.
 	GPS_POSITION = "GPSPosition"
 
+	// gps对应的geo URI,用于二维码.
+	GPS_GEO_URI = "GPSGeoURI"
+
 	// 焦段.
 	FOCAL_LENGTH = "FocalLength"
 
 	// 类型:边框.
 	PHOTO_TYPE_BORDER = "border"
 
-	// jpg图片后缀名.
-	JPG_FILE_TYPE = ".jpg"
+	// 边框位置:上.
+	SIDE_TOP = "top"
+
+	// 边框位置:下.
+	SIDE_BOTTOM = "bottom"
+
+	// 边框位置:左.
+	SIDE_LEFT = "left"
+
+	// 边框位置:右.
+	SIDE_RIGHT = "right"
+
+	// 边框内容对齐方式:阅读方向的起始位置.
+	SIDE_ALIGN_START = "start"
+
+	// 边框内容对齐方式:阅读方向的结束位置.
+	SIDE_ALIGN_END = "end"
+
+	// 边框文字默认占边框宽度的百分比.
+	SIDE_BAND_TEXT_RATIO = 35
+
+	// 文字内容中的换行符.
+	TEXT_LINE_BREAK = "\n"
+
+	// 单行输入框中无法输入换行符,可以使用\n代替.
+	TEXT_LINE_BREAK_ESCAPE = `\n`
+
+	// 默认行高,字体大小的百分比.
+	TEXT_LINE_HEIGHT = 120
+
+	// 水印位置:左上.
+	OVERLAY_ANCHOR_TOP_LEFT = "top_left"
+
+	// 水印位置:上方居中.
+	OVERLAY_ANCHOR_TOP = "top"
+
+	// 水印位置:右上.
+	OVERLAY_ANCHOR_TOP_RIGHT = "top_right"
+
+	// 水印位置:左侧居中.
+	OVERLAY_ANCHOR_LEFT = "left"
+
+	// 水印位置:居中.
+	OVERLAY_ANCHOR_CENTER = "center"
+
+	// 水印位置:右侧居中.
+	OVERLAY_ANCHOR_RIGHT = "right"
+
+	// 水印位置:左下.
+	OVERLAY_ANCHOR_BOTTOM_LEFT = "bottom_left"
 
-	JPEG_FILE_TYPE = ".jpeg"
+	// 水印位置:下方居中.
+	OVERLAY_ANCHOR_BOTTOM = "bottom"
+
+	// 水印位置:右下.
+	OVERLAY_ANCHOR_BOTTOM_RIGHT = "bottom_right"
+
+	// 水印位置:按照x与y指定的位置.
+	OVERLAY_ANCHOR_ABSOLUTE = "absolute"
+
+	// 水印重复方式:平铺.
+	OVERLAY_REPEAT_TILE = "tile"
+
+	// 水印重复方式:错位平铺,相邻两行错开半个水印的距离.
+	OVERLAY_REPEAT_DIAGONAL = "diagonal"
+
+	// 水印文字默认大小,照片短边的百分比.
+	OVERLAY_TEXT_SIZE = 4
+
+	// 重复时水印之间的默认距离,照片短边的百分比.
+	OVERLAY_REPEAT_SPACING = 10
+
+	// 自动颜色的前缀,例如auto:dominant.
+	AUTO_COLOR_PREFIX = "auto:"
+
+	// 自动颜色:照片的主色.
+	AUTO_COLOR_DOMINANT = "dominant"
+
+	// 自动颜色:照片的平均色.
+	AUTO_COLOR_AVERAGE = "average"
+
+	// 自动颜色:照片主色的补色.
+	AUTO_COLOR_COMPLEMENT = "complement"
+
+	// 自动分割线颜色向背景对比色混合的比例.
+	AUTO_SEPARATOR_MIX_RATIO = 0.3
+
+	// 自动对比度时文字与logo和背景之间的最小对比度,与WCAG中大号文字的要求一致.
+	AUTO_CONTRAST_MIN_RATIO = 3.0
+
+	// 二维码位置:展示在logo旁边.
+	QR_CODE_POSITION_LOGO = "logo"
+
+	// 二维码位置:展示在文字旁边.
+	QR_CODE_POSITION_TEXT = "text"
+
+	// 文字位置一,第一行左边.
+	TEXT_ONE = 0
+
+	// 文字位置二,第一行右边.
+	TEXT_TWO = 1
+
+	// 文字位置三,第二行左边.
+	TEXT_THREE = 2
 )
 
-// 文字内容列表.
+// 旧版本模板的文字内容字段列表.
 var textWordsList = [4]string{
 	"text_one_content",
 	"text_two_content",
 	"text_three_content",
 	"text_four_content",
 }
+
+// 上,左,右边框文字内容列表.
+var sideBandWordsList = map[string]string{
+	SIDE_TOP:   "top_band_content",
+	SIDE_LEFT:  "left_band_content",
+	SIDE_RIGHT: "right_band_content",
+}
+
+// 需要绘制文字与logo的边框位置,下边框由模板策略绘制.
+var sideBandList = [3]string{SIDE_TOP, SIDE_LEFT, SIDE_RIGHT}
@@ -11,6 +11,10 @@ import (
 
 // 将模板中的字符串转换为实际展示的字符串.
 func changeText2ExifContent(exif exiftool.FileMetadata, str string) string {
+	// 包含{{的文字使用表达式计算
+	if layout.IsTextTemplate(str) {
+		return layout.ExecuteTextTemplate(str, exif)
+	}
 	// 切割字符串
 	strList := strings.Split(str, ",")
 	exifList := make([]string, 0)
@@ -37,18 +41,24 @@ func changeText2ExifContent(exif exiftool.FileMetadata, str string) string {
 func changeExifShowStr(sub, str string, exif exiftool.FileMetadata) string {
 	// 经纬度特殊判断
 	if strings.Contains(sub, GPS_OR_DATETIME) {
-		gpsOrTime := pkg.AnyToString(exif.Fields[DATE_TIME_ORIGINAL])
-		if gpsOrTime == "" {
-			gpsOrTime = GPS_OR_DATETIME
+		return getGPSOrDateTime(exif)
+	}
+	// 城市特殊判断
+	if strings.Contains(sub, CITY_OR_GPS) {
+		if city := pkg.AnyToString(exif.Fields[layout.PLACE_CITY]); city != "" {
+			return city
 		}
-		str = layout.GetGPSOrDefault(
-			pkg.AnyToString(exif.Fields[GPS_POSITION]),
-			gpsOrTime,
-		)
 
-		return str
+		return getGPSOrDateTime(exif)
+	}
+	// 没有找到地点时不展示字段名称
+	if layout.IsPlaceField(sub) && sub == str {
+		return ""
 	}
 
+	if sub == GPS_GEO_URI {
+		return layout.GpsGeoURI(pkg.AnyToString(exif.Fields[GPS_POSITION]))
+	}
 	// 经纬度特殊判断
 	if strings.Contains(sub, GPS_POSITION) {
 		// 说明没有获取到GPS 信息
@@ -75,6 +85,19 @@ func changeExifShowStr(sub, str string, exif exiftool.FileMetadata) string {
 	return str
 }
 
+// 获取gps信息,gps信息不存在则使用时间.
+func getGPSOrDateTime(exif exiftool.FileMetadata) string {
+	gpsOrTime := pkg.AnyToString(exif.Fields[DATE_TIME_ORIGINAL])
+	if gpsOrTime == "" {
+		gpsOrTime = GPS_OR_DATETIME
+	}
+
+	return layout.GetGPSOrDefault(
+		pkg.AnyToString(exif.Fields[GPS_POSITION]),
+		gpsOrTime,
+	)
+}
+
 // 优化焦段展示,去除多余展示的.0.
 func changeFocalLength(str string) string {
 	// 去除空格
@@ -1,5 +1,6 @@
 package frame
 
+// 照片与边框的尺寸,颜色为0xRRGGBBAA格式的整数.
 type PhotoSize struct {
 	BorderLeftWidth    int
 	BorderRightWidth   int
@@ -8,6 +9,14 @@ type PhotoSize struct {
 	SourceWidth        int
 	SourceHeight       int
 	BorderRadius       int
+	ShadowBlur         int
+	ShadowOffsetX      int
+	ShadowOffsetY      int
+	ShadowOpacity      int
+	ShadowColor        int
+	StrokeWidth        int
+	StrokeColor        int
+	IsBlur             bool
 }
 
 // 返回一个图片尺寸.
@@ -49,5 +58,73 @@ func NewPhotoSize(size map[string]int) PhotoSize {
 		SourceWidth:        sourceWidth,
 		SourceHeight:       sourceHeight,
 		BorderRadius:       borderRadius,
+		// 阴影与描边不存在时为0,与取不到值时一致
+		ShadowBlur:    size["shadowBlur"],
+		ShadowOffsetX: size["shadowOffsetX"],
+		ShadowOffsetY: size["shadowOffsetY"],
+		ShadowOpacity: size["shadowOpacity"],
+		ShadowColor:   size["shadowColor"],
+		StrokeWidth:   size["strokeWidth"],
+		StrokeColor:   size["strokeColor"],
+		IsBlur:        size["isBlur"] == 1,
 	}
 }
+
+// 边框所在的区域.
+type BorderBox struct {
+	X      int
+	Y      int
+	Width  int
+	Height int
+}
+
+// 返回照片四周边框所在的区域,key为边框位置:top,bottom,left,right.
+func NewBorderBoxes(boxes map[string]map[string]int) map[string]BorderBox {
+	data := make(map[string]BorderBox, len(boxes))
+	for side, box := range boxes {
+		data[side] = BorderBox{
+			X:      box["x"],
+			Y:      box["y"],
+			Width:  box["width"],
+			Height: box["height"],
+		}
+	}
+
+	return data
+}
+
+// 文字中的一行所在的区域,Name为文字内容对应的字段名称,Index为行号.
+type TextLineBox struct {
+	Name   string
+	Text   string
+	Index  int
+	X      int
+	Y      int
+	Width  int
+	Height int
+}
+
+// 返回边框文字每一行所在的区域.
+func NewTextLineBoxes(lines []map[string]any) []TextLineBox {
+	data := make([]TextLineBox, 0, len(lines))
+	for _, line := range lines {
+		name, _ := line["name"].(string)
+		text, _ := line["text"].(string)
+		index, _ := line["index"].(int)
+		x, _ := line["x"].(int)
+		y, _ := line["y"].(int)
+		width, _ := line["width"].(int)
+		height, _ := line["height"].(int)
+		data = append(data, TextLineBox{
+			Name:   name,
+			Text:   text,
+			Index:  index,
+			X:      x,
+			Y:      y,
+			Width:  width,
+			Height: height,
+		})
+	}
+
+	return data
+}
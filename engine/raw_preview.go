@@ -0,0 +1,98 @@
+package engine
+
+import (
+	"bytes"
+	"image/jpeg"
+	"os"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/yijianlingcheng/go-exiftool"
+
+	"WaterMark/internal"
+	"WaterMark/internal/cmd"
+	"WaterMark/pkg"
+)
+
+var (
+	// RAW文件中内嵌预览图片的标签.
+	rawPreviewTags = []string{"JpgFromRaw", "PreviewImage", "OtherImage"}
+
+	// 从exif的二进制描述中获取数据长度,例如:(Binary data 1234 bytes, use -b option to extract).
+	rawPreviewSizeRegexp = regexp.MustCompile(`(\d+) bytes`)
+)
+
+// 提取RAW文件内嵌的预览图片,并将exif中的宽高替换为预览图片的宽高,其余exif信息保持RAW文件的信息.
+func setRawPreviewExif(path string, exifInfo exiftool.FileMetadata) pkg.EError {
+	previewPath, err := ExtractRawPreview(path, exifInfo)
+	if pkg.HasError(err) {
+		return err
+	}
+	file, openErr := os.Open(previewPath)
+	if openErr != nil {
+		return pkg.NewErrors(pkg.FILE_NOT_OPEN_ERROR, previewPath+":文件打开失败:"+openErr.Error())
+	}
+	defer file.Close()
+
+	config, decodeErr := jpeg.DecodeConfig(file)
+	if decodeErr != nil {
+		return pkg.NewErrors(pkg.IMAGE_DECODE_ERROR, previewPath+":预览图片解码失败:"+decodeErr.Error())
+	}
+	exifInfo.Fields["ImageWidth"] = float64(config.Width)
+	exifInfo.Fields["ImageHeight"] = float64(config.Height)
+
+	return pkg.NoError
+}
+
+// 提取RAW文件中最大的内嵌jpg预览图片,缓存在runtime/raw文件夹中.
+// 二进制数据需要exiftool的-b参数输出,常驻的exiftool实例不支持,所以单独调用exiftool执行.
+func ExtractRawPreview(path string, exifInfo exiftool.FileMetadata) (string, pkg.EError) {
+	savePath := internal.GetAppRawPreviewFilePath(path)
+	if internal.PathExists(savePath) {
+		return savePath, pkg.NoError
+	}
+	tag := getRawPreviewTag(exifInfo)
+	if tag == "" {
+		return "", pkg.NewErrors(pkg.IMAGE_RAW_PREVIEW_ERROR, path+":RAW文件中没有内嵌的预览图片")
+	}
+	// -q -q 不输出警告信息,保证输出内容只有图片数据
+	args := []string{internal.GetExiftoolPath(), "-q", "-q", "-b", "-" + tag, path}
+	// 标准错误不能混入图片数据,只读取标准输出
+	data, cmdErr := cmd.CommandOutputWithArgs(time.Minute, args)
+	if pkg.HasError(cmdErr) {
+		return "", pkg.NewErrors(pkg.IMAGE_RAW_PREVIEW_ERROR, path+":提取RAW预览图片失败:"+cmdErr.Error.Error())
+	}
+	if !bytes.HasPrefix(data, []byte{0xFF, 0xD8}) {
+		return "", pkg.NewErrors(pkg.IMAGE_RAW_PREVIEW_ERROR, path+":RAW文件内嵌的"+tag+"不是jpg图片")
+	}
+	// 先写入临时文件,避免并发读取到未写完的文件
+	tmpPath := savePath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o600); err != nil {
+		return "", pkg.NewErrors(pkg.FILE_NOT_OPEN_ERROR, tmpPath+":RAW预览图片保存失败:"+err.Error())
+	}
+	if err := os.Rename(tmpPath, savePath); err != nil {
+		return "", pkg.NewErrors(pkg.FILE_NOT_OPEN_ERROR, savePath+":RAW预览图片保存失败:"+err.Error())
+	}
+
+	return savePath, pkg.NoError
+}
+
+// 获取数据最大的预览图片标签,没有时返回空字符串.
+func getRawPreviewTag(exifInfo exiftool.FileMetadata) string {
+	tag := ""
+	maxSize := 0
+	for _, name := range rawPreviewTags {
+		match := rawPreviewSizeRegexp.FindStringSubmatch(pkg.AnyToString(exifInfo.Fields[name]))
+		if len(match) < 2 {
+			continue
+		}
+		size, _ := strconv.Atoi(match[1])
+		if size > maxSize {
+			tag = name
+			maxSize = size
+		}
+	}
+
+	return tag
+}
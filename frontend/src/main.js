@@ -626,6 +626,36 @@ function setWidthLtHeight() {
     lastWidthAndHeightFlag = 0
 }
 
+// 0xRRGGBBAA格式的颜色转换为css颜色
+function intColor2Css(value) {
+    let r = (value >>> 24) & 255
+    let g = (value >>> 16) & 255
+    let b = (value >>> 8) & 255
+    let a = (value & 255) / 255
+
+    return "rgba(" + r + ", " + g + ", " + b + ", " + a + ")"
+}
+// 预览中照片的圆角,阴影与描边,scale为预览与原图的比例,普通边框的阴影已经绘制在边框图片中
+function getPreviewPhotoCss(data, scale) {
+    // 切换模板时需要清除上一次的样式
+    let css = { "border-radius": "0px", "box-shadow": "none", "outline": "none" }
+    let radius = parseInt(data["BorderRadius"] * scale)
+    if (radius > 0) {
+        css["border-radius"] = radius + "px"
+    }
+    let blur = parseInt(data["ShadowBlur"] * scale)
+    if (data["IsBlur"] && blur > 0) {
+        let color = intColor2Css(data["ShadowColor"] & ~255 | parseInt(data["ShadowOpacity"] * 255 / 100))
+        css["box-shadow"] = parseInt(data["ShadowOffsetX"] * scale) + "px " + parseInt(data["ShadowOffsetY"] * scale) + "px " + blur * 2 + "px " + color
+    }
+    let stroke = Math.max(Math.round(data["StrokeWidth"] * scale), data["StrokeWidth"] > 0 ? 1 : 0)
+    if (stroke > 0) {
+        css["outline"] = stroke + "px solid " + intColor2Css(data["StrokeColor"])
+        css["outline-offset"] = -stroke + "px"
+    }
+
+    return css
+}
 
 const FramePreviewBorderDomProcess = {
     // 加载原始图片
@@ -755,7 +785,8 @@ const FramePreviewBorderDomProcess = {
         let borderBottom = parseInt(data["BorderBottomHeight"])
         let width = parseInt(data["SourceWidth"])
         let height = parseInt(data["SourceHeight"])
-        let borderRadius = parseInt(data["BorderRadius"])
+        // 普通边框的圆角不需要展示模糊背景
+        let borderRadius = data["IsBlur"] ? parseInt(data["BorderRadius"]) : 0
         let totalWidth = borderLeft + borderRight + width
         let totalHeight = borderTop + borderBottom + height
 
@@ -782,11 +813,7 @@ const FramePreviewBorderDomProcess = {
             // 原始图片展示宽度
             let originShowWidth = showWidth - marginLeft - marginRight
             let originCss = { "margin-top": marginTop + "px", "margin-left": (marginLeft - showWidth) + "px", "width": (originShowWidth) + "px", "height": "auto" }
-            if (borderRadius > 0) {
-                let radius = parseInt(originShowWidth / width * borderRadius)
-                originCss["border-radius"] = radius + "px"
-                originCss["box-shadow"] = "0px 0px " + radius + "px " + radius / 2 + "px rgba(128, 128, 128, 0.5)"
-            }
+            Object.assign(originCss, getPreviewPhotoCss(data, originShowWidth / width))
             $("#images-container").css({ "height": "auto", "width": showWidth + "px" })
             $("#images-origin").css(originCss)
 
@@ -827,11 +854,7 @@ const FramePreviewBorderDomProcess = {
             }
 
             let originCss = { "height": newOriginShowHegiht + "px", "width": newOriginShowWidth + "px", "margin-left": marginLeft + "px", "margin-top": marginTop + "px" }
-            if (borderRadius > 0) {
-                let radius = parseInt(newOriginShowHegiht / height * borderRadius)
-                originCss["border-radius"] = radius + "px"
-                originCss["box-shadow"] = "0px 0px " + radius + "px " + radius / 2 + "px rgba(128, 128, 128, 0.5)"
-            }
+            Object.assign(originCss, getPreviewPhotoCss(data, newOriginShowHegiht / height))
             let containerCss = { "height": showHeight + "px", "width": newShowWidth + "px" }
             // 调整模糊模板的容器大小
             $("#images-container").css(containerCss)
@@ -44,7 +44,7 @@ func InitAppConfigsAndRes() {
 		message.SendErrorOrInfo(err, "exiftool工具已安装")
 
 		message.SendInfoMsg("检查ImageMagick工具是否安装")
-		err = checkInstallImageMagick()
+		err = checkOptionalImageMagick()
 		message.SendErrorOrInfo(err, "ImageMagick工具已安装")
 
 		// 释放字体文件
@@ -24,11 +24,15 @@ func checkInstallExif() pkg.EError {
 
 // 检查exif工具.
 func checkExiftool() pkg.EError {
-	args := []string{GetExiftoolPath(), "-ver"}
+	exiftoolPath := GetExiftoolPath()
+	args := []string{exiftoolPath, "-ver"}
 	version, err := cmd.CommandRun(5*time.Second, strings.Join(args, " "))
 
 	if version == "" || pkg.HasError(err) {
-		return pkg.ExiftoolNotExistError
+		return pkg.NewErrors(
+			pkg.ExiftoolNotExistError.Code,
+			exiftoolPath+":"+pkg.ExiftoolNotExistError.Error.Error()+","+exiftoolInstallHint(),
+		)
 	}
 
 	return pkg.NoError
@@ -78,14 +82,54 @@ func checkInstallImageMagick() pkg.EError {
 	return pkg.NoError
 }
 
+// 检查ImageMagick工具,原生合成模式下ImageMagick不是必须的,未安装时只记录日志.
+func checkOptionalImageMagick() pkg.EError {
+	err := checkInstallImageMagick()
+	if pkg.HasError(err) && !IsMagickBlurCompositor() {
+		Log.Warn(err.String())
+
+		return pkg.NoError
+	}
+
+	return err
+}
+
 // 检查ImageMagick工具.
 func checkImageMagick() pkg.EError {
-	args := []string{GetMagickBinPath(), "-version"}
+	magickPath := GetMagickBinPath()
+	args := []string{magickPath, "-version"}
 	version, err := cmd.CommandRun(5*time.Second, strings.Join(args, " "))
 
 	if version == "" || pkg.HasError(err) {
-		return pkg.ExiftoolNotExistError
+		return pkg.NewErrors(
+			pkg.ImageMagickNotExistError.Code,
+			magickPath+":"+pkg.ImageMagickNotExistError.Error.Error()+","+magickInstallHint(),
+		)
 	}
 
 	return pkg.NoError
 }
+
+// exiftool工具的安装提示.
+func exiftoolInstallHint() string {
+	if IsWindows() {
+		return "请检查程序目录下的exiftool文件夹是否完整,或删除该文件夹后重启程序"
+	}
+	if IsLinux() {
+		return "请执行apt install libimage-exiftool-perl安装,或在configs/app.yaml中配置tools.exiftool-dir"
+	}
+
+	return "请执行brew install exiftool安装,并将exiftool加入环境变量"
+}
+
+// ImageMagick工具的安装提示.
+func magickInstallHint() string {
+	if IsWindows() {
+		return "请检查程序目录下的magick文件夹是否完整,或删除该文件夹后重启程序"
+	}
+	if IsLinux() {
+		return "请执行apt install imagemagick安装,或在configs/app.yaml中配置tools.magick-dir"
+	}
+
+	return "请执行brew install imagemagick安装,并将magick加入环境变量"
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"strings"
+	"time"
+
+	"WaterMark/pkg"
+)
+
+// CommandRun linux 使用sh运行命令.
+func CommandRun(timeout time.Duration, args string) (string, pkg.EError) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", args)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout // 标准输出
+	cmd.Stderr = &stderr // 标准错误
+
+	err := cmd.Run()
+	outStr, errStr := stdout.String(), stderr.String()
+
+	cmdErr := pkg.NoError
+	if err != nil {
+		errStr = err.Error() + errStr + ":" + args
+		cmdErr = pkg.NewErrors(pkg.CMD_COMMAND_RUN_ERROR, errStr)
+	}
+
+	return outStr, cmdErr
+}
+
+// CommandRunWithArgs linux 直接运行可执行文件,不经过shell,参数中的括号等字符无需转义.
+func CommandRunWithArgs(timeout time.Duration, args []string) (string, pkg.EError) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	//nolint:gosec
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+
+	out, err := cmd.CombinedOutput()
+
+	cmdErr := pkg.NoError
+	if err != nil {
+		errStr := err.Error() + string(out) + ":" + strings.Join(args, " ")
+		cmdErr = pkg.NewErrors(pkg.CMD_COMMAND_RUN_ERROR, errStr)
+	}
+
+	return string(out), cmdErr
+}
+
+// CommandOutputWithArgs linux 直接运行可执行文件,只返回标准输出,标准错误放在错误信息中,用于读取二进制输出.
+func CommandOutputWithArgs(timeout time.Duration, args []string) ([]byte, pkg.EError) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	//nolint:gosec
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout // 标准输出
+	cmd.Stderr = &stderr // 标准错误
+
+	err := cmd.Run()
+
+	cmdErr := pkg.NoError
+	if err != nil {
+		errStr := stderr.String()
+		errStr = err.Error() + errStr + ":" + strings.Join(args, " ")
+		cmdErr = pkg.NewErrors(pkg.CMD_COMMAND_RUN_ERROR, errStr)
+	}
+
+	return stdout.Bytes(), cmdErr
+}
@@ -73,6 +73,31 @@ func CommandRunWithArgs(timeout time.Duration, args []string) (string, pkg.EErro
 	return string(out), cmdErr
 }
 
+// CommandOutputWithArgs windows 直接运行可执行文件,只返回标准输出,标准错误放在错误信息中,用于读取二进制输出.
+func CommandOutputWithArgs(timeout time.Duration, args []string) ([]byte, pkg.EError) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	//nolint:gosec
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	hideWindowCmd(cmd)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout // 标准输出
+	cmd.Stderr = &stderr // 标准错误
+
+	err := cmd.Run()
+
+	cmdErr := pkg.NoError
+	if err != nil {
+		errStr := changeToUTF8String(stderr.String(), GB18030)
+		errStr = err.Error() + errStr + ":" + strings.Join(args, " ")
+		cmdErr = pkg.NewErrors(pkg.CMD_COMMAND_RUN_ERROR, errStr)
+	}
+
+	return stdout.Bytes(), cmdErr
+}
+
 // 将字节切片转换为指定编码的字符串.
 func changeToUTF8String(bytes string, charset charset) string {
 	var str string
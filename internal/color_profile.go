@@ -0,0 +1,55 @@
+package internal
+
+import (
+	"image"
+
+	"WaterMark/pkg"
+)
+
+// 获取图片处理与导出使用的色彩配置文件,配置错误时使用sRGB.
+func GetWorkingColorProfile() *pkg.ColorProfile {
+	profile, err := pkg.GetColorProfile(GetWorkingColorSpace())
+	if pkg.HasError(err) {
+		Log.Warn(err.String())
+
+		return pkg.GetSRGBColorProfile()
+	}
+
+	return profile
+}
+
+// 加载图片并按照嵌入的ICC配置文件转换到工作色彩空间.
+func LoadImageWithWorkingColorSpace(path string) (image.Image, pkg.EError) {
+	decodePath, convertErr := getDecodableImagePath(path)
+	if pkg.HasError(convertErr) {
+		return nil, convertErr
+	}
+	img, err := pkg.LoadImageWithDecode(decodePath)
+	if pkg.HasError(err) {
+		return nil, err
+	}
+	profile := readImageColorProfile(decodePath)
+
+	return pkg.ConvertImageColorProfile(img, profile, GetWorkingColorProfile()), pkg.NoError
+}
+
+// 读取图片嵌入的ICC配置文件,没有嵌入或者不支持的配置文件按照sRGB处理.
+func readImageColorProfile(path string) *pkg.ColorProfile {
+	data, err := pkg.ReadColorProfileFromFile(path)
+	if pkg.HasError(err) {
+		Log.Warn(err.String())
+
+		return pkg.GetSRGBColorProfile()
+	}
+	if len(data) == 0 {
+		return pkg.GetSRGBColorProfile()
+	}
+	profile, parseErr := pkg.ParseColorProfile(data)
+	if pkg.HasError(parseErr) {
+		Log.Warn(path + ":" + parseErr.String())
+
+		return pkg.GetSRGBColorProfile()
+	}
+
+	return profile
+}
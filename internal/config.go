@@ -14,6 +14,15 @@ const (
 	APP_API_DEV = "api_debug"
 	// 发布模式.
 	APP_RELEASE = "release"
+
+	// 模糊模板使用ImageMagick合成圆角阴影.
+	BLUR_COMPOSITOR_MAGICK = "magick"
+
+	// 地名默认使用的语言.
+	PLACE_LANGUAGE = "zh"
+
+	// 反向地理编码默认的最大距离,单位为公里.
+	PLACE_RADIUS = 50.0
 )
 
 // 程序运行模式.
@@ -75,3 +84,58 @@ func ISApiDebug() bool {
 func GetPlugin() string {
 	return viper.GetString("frame.plugin")
 }
+
+// 模糊模板是否使用ImageMagick合成圆角阴影,默认使用原生代码合成.
+func IsMagickBlurCompositor() bool {
+	return viper.GetString("frame.blur-compositor") == BLUR_COMPOSITOR_MAGICK
+}
+
+// 获取图片处理与导出使用的色彩空间,默认sRGB.
+func GetWorkingColorSpace() string {
+	space := viper.GetString("frame.color-space")
+	if space == "" {
+		return pkg.COLOR_SPACE_SRGB
+	}
+
+	return space
+}
+
+// 获取隐形水印的所有者ID,为空时不嵌入隐形水印.
+func GetWatermarkOwnerID() string {
+	return viper.GetString("watermark.owner-id")
+}
+
+// 获取配置的离线地名数据库,为空时使用configs/places.csv.
+func GetPlaceDatabase() string {
+	return viper.GetString("geocode.database")
+}
+
+// 获取地名使用的语言,默认中文.
+func GetPlaceLanguage() string {
+	language := viper.GetString("geocode.language")
+	if language == "" {
+		return PLACE_LANGUAGE
+	}
+
+	return language
+}
+
+// 获取反向地理编码的最大距离,超出该距离时当作没有找到地点.
+func GetPlaceRadius() float64 {
+	radius := viper.GetFloat64("geocode.radius")
+	if radius <= 0 {
+		return PLACE_RADIUS
+	}
+
+	return radius
+}
+
+// 获取配置的exiftool所在文件夹.
+func GetExiftoolDir() string {
+	return viper.GetString("tools.exiftool-dir")
+}
+
+// 获取配置的ImageMagick所在文件夹.
+func GetMagickDir() string {
+	return viper.GetString("tools.magick-dir")
+}
@@ -0,0 +1,59 @@
+package internal
+
+import (
+	"os"
+	"time"
+
+	"WaterMark/internal/cmd"
+	"WaterMark/pkg"
+)
+
+// 获取可以直接解码的图片路径,HEIC/AVIF图片使用ImageMagick转换为png.
+// RAW文件使用获取exif信息时提取的内嵌预览图片.
+func getDecodableImagePath(path string) (string, pkg.EError) {
+	if pkg.IsRawImageFile(path) {
+		previewPath := GetAppRawPreviewFilePath(path)
+		if !PathExists(previewPath) {
+			return "", pkg.NewErrors(pkg.IMAGE_RAW_PREVIEW_ERROR, path+":RAW文件的预览图片不存在")
+		}
+
+		return previewPath, pkg.NoError
+	}
+	format, err := pkg.GetImageFormat(path)
+	if pkg.HasError(err) {
+		return "", err
+	}
+	if !pkg.IsMagickConvertFormat(format) {
+		return path, pkg.NoError
+	}
+
+	return convertImageWithMagick(path)
+}
+
+// 使用ImageMagick将图片转换为png,转换结果缓存在runtime/convert文件夹中.
+// 保持图片存储时的方向,由exif中的方向信息统一旋转.
+func convertImageWithMagick(path string) (string, pkg.EError) {
+	savePath := GetAppConvertFilePath(pkg.GetStrMD5(path) + ".png")
+	if PathExists(savePath) {
+		return savePath, pkg.NoError
+	}
+	// 先写入临时文件,避免并发读取到未写完的文件
+	tmpPath := savePath + ".tmp"
+	args := []string{
+		GetMagickBinPath(),
+		"-define", "heic:preserve-orientation=true",
+		path + "[0]",
+		"png:" + tmpPath,
+	}
+	_, cmdErr := cmd.CommandRunWithArgs(5*time.Minute, args)
+	if pkg.HasError(cmdErr) {
+		Log.Error(path + ":ImageMagick转换图片失败:" + cmdErr.String())
+
+		return "", pkg.NewErrors(pkg.IMAGE_NO_SUPPORT_ERROR, path+":HEIC/AVIF图片需要安装ImageMagick进行转换")
+	}
+	if err := os.Rename(tmpPath, savePath); err != nil {
+		return "", pkg.NewErrors(pkg.FILE_NOT_OPEN_ERROR, savePath+":转换图片保存失败:"+err.Error())
+	}
+
+	return savePath, pkg.NoError
+}
@@ -23,7 +23,7 @@ func CacheLoadImageWithDecode(path string) (image.Image, pkg.EError) {
 		return cache, pkg.NoError
 	}
 
-	image, err := pkg.LoadImageWithDecode(path)
+	image, err := LoadImageWithWorkingColorSpace(path)
 	if pkg.HasError(err) {
 		message.SendErrorMsg(path + ":加载图片文件失败:" + err.String())
 
@@ -56,7 +56,7 @@ func ImportImageFiles(paths []string, exifInfos []exiftool.FileMetadata) {
 
 				return
 			}
-			image, err := pkg.LoadImageWithDecode(path)
+			image, err := LoadImageWithWorkingColorSpace(path)
 			if pkg.HasError(err) {
 				<-ch
 
@@ -0,0 +1,57 @@
+package internal
+
+import (
+	"fmt"
+	"time"
+
+	"WaterMark/pkg"
+)
+
+// 照片中隐形水印的校验结果.
+type InvisibleWatermarkInfo struct {
+	// 照片路径
+	File string `json:"file"`
+	// 读取到水印的层,image为整张图片,photo为裁剪掉边框之后的照片区域,tile为照片区域中按照原始像素平铺的水印
+	Layer string `json:"layer"`
+	// 水印中所有者ID的hash
+	OwnerHash string `json:"owner_hash"`
+	// 导出时间
+	ExportTime string `json:"export_time"`
+	// 导出时间的时间戳
+	Timestamp int64 `json:"timestamp"`
+	// 与读取结果一致的系数比例,越接近1越可信
+	Agreement float64 `json:"agreement"`
+	// 是否读取到隐形水印
+	Found bool `json:"found"`
+	// 水印的所有者是否与指定的所有者ID一致
+	OwnerMatch bool `json:"owner_match"`
+}
+
+// 读取照片中的隐形水印,owner为空时与配置的所有者ID比较.
+func VerifyInvisibleWatermark(path, owner string) (InvisibleWatermarkInfo, pkg.EError) {
+	info := InvisibleWatermarkInfo{File: path}
+	decodePath, err := getDecodableImagePath(path)
+	if pkg.HasError(err) {
+		return info, err
+	}
+	img, err := pkg.LoadImageWithDecode(decodePath)
+	if pkg.HasError(err) {
+		return info, err
+	}
+	mark, ok := pkg.ExtractInvisibleWatermark(img)
+	if !ok {
+		return info, pkg.NoError
+	}
+	if owner == "" {
+		owner = GetWatermarkOwnerID()
+	}
+	info.Layer = mark.Layer
+	info.OwnerHash = fmt.Sprintf("%08x", mark.OwnerHash)
+	info.ExportTime = time.Unix(mark.Timestamp, 0).Format(time.DateTime)
+	info.Timestamp = mark.Timestamp
+	info.Agreement = mark.Agreement
+	info.Found = true
+	info.OwnerMatch = owner != "" && mark.OwnerHash == pkg.InvisibleWatermarkOwnerHash(owner)
+
+	return info, pkg.NoError
+}
@@ -3,9 +3,13 @@ package internal
 import (
 	"errors"
 	"os"
+	"os/exec"
 	"path"
 	"path/filepath"
 	"runtime"
+	"strconv"
+
+	"WaterMark/pkg"
 )
 
 var (
@@ -90,6 +94,25 @@ func GetMainLayoutPath() string {
 	return GetRootPath() + appConfigsPath + "/layout.json"
 }
 
+// 获取相机与镜头名称映射文件.
+func GetDisplayNamePath() string {
+	return GetRootPath() + appConfigsPath + "/display_name.json"
+}
+
+// 获取离线地名数据库文件,没有配置时使用内置的地名文件.
+func GetPlaceDatabasePath() string {
+	if file := GetPlaceDatabase(); file != "" {
+		return file
+	}
+
+	return GetRootPath() + appConfigsPath + "/places.csv"
+}
+
+// 获取logo匹配规则文件.
+func GetLogoRulePath() string {
+	return GetRootPath() + appConfigsPath + "/logo_rule.json"
+}
+
 // 获取ImageMagick可执行文件路径.
 func GetMagickPath(p string) string {
 	return GetRootPath() + magickPath + "/" + p
@@ -105,6 +128,9 @@ func GetMagickBinPath() string {
 	if IsWindows() {
 		return GetMagickPath(magick + ".exe")
 	}
+	if IsLinux() {
+		return lookupToolPath(GetMagickDir(), magick, magickConvert)
+	}
 
 	return magick
 }
@@ -114,10 +140,36 @@ func GetExiftoolPath() string {
 	if IsWindows() {
 		return GetRootPath() + appWinExiftoolPath
 	}
+	if IsLinux() {
+		return lookupToolPath(GetExiftoolDir(), appLinuxExiftoolPath)
+	}
 
 	return appDarwinExiftoolPath
 }
 
+// 查找可执行文件路径.
+// 配置了文件夹时只在该文件夹中查找,否则从PATH中查找,都没有找到时返回第一个名称,由调用方报告错误.
+func lookupToolPath(dir string, names ...string) string {
+	for _, name := range names {
+		if dir != "" {
+			toolPath := filepath.Join(dir, name)
+			if PathExists(toolPath) {
+				return toolPath
+			}
+
+			continue
+		}
+		if toolPath, err := exec.LookPath(name); err == nil {
+			return toolPath
+		}
+	}
+	if dir != "" {
+		return filepath.Join(dir, names[0])
+	}
+
+	return names[0]
+}
+
 // 初始化程序需要的各种文件夹.
 func createAppDS(list []string) {
 	for _, i := range list {
@@ -145,12 +197,48 @@ func GetAppBlurFilePath(p string) string {
 	return GetRootPath() + appBlurPath + "/" + p
 }
 
+// 获取ImageMagick转换之后的图片文件路径.
+func GetAppConvertFilePath(p string) string {
+	return GetRootPath() + appConvertPath + "/" + p
+}
+
+// 获取RAW文件内嵌预览图片的缓存路径.
+func GetAppRawPreviewFilePath(rawPath string) string {
+	return GetRootPath() + appRawPath + "/" + pkg.GetStrMD5(rawPath) + ".jpg"
+}
+
+// 命令行使用当前进程独立的缓存文件夹,清理时不会删除UI正在使用的缓存,需要在初始化配置之前调用.
+func UseCommandCacheDir() {
+	appCommandCachePath = appRuntimePath + "/cli-" + strconv.Itoa(os.Getpid())
+	appBlurPath = appCommandCachePath + "/blur"
+	appConvertPath = appCommandCachePath + "/convert"
+	appRawPath = appCommandCachePath + "/raw"
+	appRunNeedDS = append(appRunNeedDS, appCommandCachePath, appBlurPath, appConvertPath, appRawPath)
+}
+
 // 清理程序运行时产生的临时文件夹.
 func CleanDir() {
+	if appCommandCachePath != "" {
+		os.RemoveAll(GetRootPath() + appCommandCachePath)
+
+		return
+	}
 	delBlurPath()
+	delConvertPath()
+	delRawPath()
 }
 
 // 删除模糊图片缓存文件夹.
 func delBlurPath() {
 	os.RemoveAll(GetRootPath() + appBlurPath)
 }
+
+// 删除ImageMagick转换图片缓存文件夹.
+func delConvertPath() {
+	os.RemoveAll(GetRootPath() + appConvertPath)
+}
+
+// 删除RAW预览图片缓存文件夹.
+func delRawPath() {
+	os.RemoveAll(GetRootPath() + appRawPath)
+}
@@ -24,18 +24,18 @@ func winRestoreExitoolZipFile() pkg.EError {
 		// 从文件中释放exiftool.zip文件
 		err := assetexiffs.RestoreAssets(GetRootPath(), "exiftool")
 		if err != nil {
-			Log.Panic("exiftool.zip文件释放失败,程序异常退出:" + err.Error())
+			return pkg.NewErrors(pkg.TOOL_RESTORE_ERROR, "exiftool.zip文件释放失败:"+err.Error()+","+exiftoolInstallHint())
 		}
-		// 判断文件是否释放成功,释放失败程序直接终止
+		// 判断文件是否释放成功
 		if !PathExists(GetExiftoolZipPath()) {
-			Log.Panic("exiftool.zip文件释放失败,程序异常退出")
+			return pkg.NewErrors(pkg.TOOL_RESTORE_ERROR, GetExiftoolZipPath()+":exiftool.zip文件释放失败,"+exiftoolInstallHint())
 		}
 		// 解压文件
 		Unzip(GetExiftoolZipPath(), GetExiftoolUnzipPath())
 
 		// 判断zip是否解压成功
 		if !PathExists(GetExiftoolPath()) {
-			Log.Panic("exiftool.zip文件解压失败,程序异常退出")
+			return pkg.NewErrors(pkg.TOOL_RESTORE_ERROR, GetExiftoolZipPath()+":exiftool.zip文件解压失败,"+exiftoolInstallHint())
 		}
 	}
 
@@ -52,26 +52,27 @@ func winRestoreImagemagick7zFile() pkg.EError {
 		// 从文件中释放ImageMagick.7z文件
 		err := assetmagickfs.RestoreAssets(GetRootPath(), magick)
 		if err != nil {
-			Log.Panic("ImageMagick.7z文件释放失败,程序异常退出:" + err.Error())
+			return pkg.NewErrors(pkg.TOOL_RESTORE_ERROR, "ImageMagick.7z文件释放失败:"+err.Error()+","+magickInstallHint())
 		}
-		// 判断文件是否释放成功,释放失败程序直接终止
+		// 判断文件是否释放成功
 		if !PathExists(GetWinMagick7zPath()) {
-			Log.Panic("ImageMagick.7z文件释放失败,程序异常退出")
+			return pkg.NewErrors(pkg.TOOL_RESTORE_ERROR, GetWinMagick7zPath()+":ImageMagick.7z文件释放失败,"+magickInstallHint())
 		}
 		// 解压文件
 		Unzip7z(GetWinMagick7zPath(), GetMagickPath(""))
-		editImageMagickConfig()
+
+		return editImageMagickConfig()
 	}
 
 	return pkg.NoError
 }
 
 // 修改ImageMagick配置文件,调整多线程处理能力.防止cpu占用过高.
-func editImageMagickConfig() {
+func editImageMagickConfig() pkg.EError {
 	policyPath := GetMagickPath("policy.xml")
 	content, err := os.ReadFile(policyPath)
 	if err != nil {
-		Log.Panic("读取ImageMagick配置文件失败,程序异常退出:" + err.Error())
+		return pkg.NewErrors(pkg.FILE_NOT_READ_ERROR, policyPath+":读取ImageMagick配置文件失败:"+err.Error())
 	}
 	// 限制cpu使用数量在4~8之间
 	cpu := min(max(runtime.NumCPU(), 4), 8)
@@ -84,8 +85,10 @@ func editImageMagickConfig() {
 	)
 	err = os.WriteFile(policyPath, []byte(newContent), 0o600)
 	if err != nil {
-		Log.Panic("写入ImageMagick配置文件失败,程序异常退出:" + err.Error())
+		return pkg.NewErrors(pkg.FILE_NOT_OPEN_ERROR, policyPath+":写入ImageMagick配置文件失败:"+err.Error())
 	}
+
+	return pkg.NoError
 }
 
 // 释放字体文件.
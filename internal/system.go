@@ -25,6 +25,13 @@ var (
 
 	appBlurPath = appRuntimePath + "/blur"
 
+	appConvertPath = appRuntimePath + "/convert"
+
+	appRawPath = appRuntimePath + "/raw"
+
+	// 命令行使用的缓存文件夹,为空时使用UI的缓存文件夹.
+	appCommandCachePath = ""
+
 	appUserPath = "/userData"
 
 	magickPath = "/magick"
@@ -38,6 +45,8 @@ var (
 		appConfigsPath,
 		appRuntimePath,
 		appBlurPath,
+		appConvertPath,
+		appRawPath,
 		appUserPath,
 		appFontFilePath,
 	}
@@ -50,6 +59,12 @@ var (
 
 	// MacOS 系统中的exiftool可执行文件路径.
 	appDarwinExiftoolPath = "exiftool"
+
+	// linux系统中的exiftool可执行文件名称.
+	appLinuxExiftoolPath = "exiftool"
+
+	// ImageMagick6 的可执行文件名称,部分linux发行版只提供convert命令.
+	magickConvert = "convert"
 )
 
 // 获取APP运行时需要的全部文件夹列表.
@@ -67,3 +82,8 @@ func getAppAllRuntimeDS() []string {
 func IsWindows() bool {
 	return pkg.IsWindows()
 }
+
+// 检查当前运行环境是否为linux.
+func IsLinux() bool {
+	return pkg.IsLinux()
+}
@@ -0,0 +1,180 @@
+package layout
+
+import (
+	"encoding/json"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/yijianlingcheng/go-exiftool"
+
+	"WaterMark/internal"
+	"WaterMark/pkg"
+)
+
+type (
+	// 相机与镜头名称映射文件.
+	displayNameRules struct {
+		List []DisplayNameRule `json:"list"`
+	}
+
+	// 名称映射规则,按照文件中的顺序依次执行.
+	DisplayNameRule struct {
+		regexp *regexp.Regexp
+		// 相机厂商,不区分大小写,exif中的Make包含此值时生效,为空时对全部厂商生效.
+		Make string `json:"make"`
+		// exif字段,为空时对Model与LensModel生效.
+		Field string `json:"field"`
+		// 正则表达式.
+		Pattern string `json:"pattern"`
+		// 替换内容,支持$1引用分组.
+		Replace string `json:"replace"`
+	}
+
+	// 字段映射前后的值.
+	DisplayName struct {
+		Field      string `json:"field"`
+		Raw        string `json:"raw"`
+		Normalized string `json:"normalized"`
+	}
+)
+
+var (
+	// 已经加载的映射规则,为nil时表示没有加载.
+	displayNames []DisplayNameRule
+
+	// 读写映射规则使用的锁.
+	displayNamesMtx sync.RWMutex
+
+	// 没有指定字段时生效的exif字段.
+	defaultDisplayNameFields = []string{"Model", "LensModel"}
+)
+
+// 重新加载相机与镜头名称映射文件,文件不存在时不做映射.
+func ReloadDisplayNames() pkg.EError {
+	rules, err := loadDisplayNames()
+	if pkg.HasError(err) {
+		return err
+	}
+	displayNamesMtx.Lock()
+	displayNames = rules
+	displayNamesMtx.Unlock()
+
+	return pkg.NoError
+}
+
+// 读取并解析名称映射文件.
+func loadDisplayNames() ([]DisplayNameRule, pkg.EError) {
+	rules := make([]DisplayNameRule, 0)
+	file := internal.GetDisplayNamePath()
+	if !internal.PathExists(file) {
+		return rules, pkg.NoError
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return rules, pkg.NewErrors(pkg.FILE_NOT_READ_ERROR, file+":名称映射文件打开失败")
+	}
+	var list displayNameRules
+	if err = json.Unmarshal(content, &list); err != nil {
+		return rules, pkg.NewErrors(pkg.FILE_NOT_READ_ERROR, file+":名称映射文件json解析失败")
+	}
+	for _, rule := range list.List {
+		rule.regexp, err = regexp.Compile(rule.Pattern)
+		if err != nil {
+			return rules, pkg.NewErrors(pkg.LAYOUT_DISPLAY_NAME_ERROR, rule.Pattern+":名称映射正则表达式错误:"+err.Error())
+		}
+		rule.Make = strings.ToLower(rule.Make)
+		rules = append(rules, rule)
+	}
+
+	return rules, pkg.NoError
+}
+
+// 获取映射规则,没有加载时先加载,加载失败不做映射.
+func getDisplayNameRules() []DisplayNameRule {
+	displayNamesMtx.RLock()
+	rules := displayNames
+	displayNamesMtx.RUnlock()
+	if rules != nil {
+		return rules
+	}
+	if err := ReloadDisplayNames(); pkg.HasError(err) {
+		internal.Log.Error(err.String())
+	}
+	displayNamesMtx.Lock()
+	defer displayNamesMtx.Unlock()
+	if displayNames == nil {
+		displayNames = make([]DisplayNameRule, 0)
+	}
+
+	return displayNames
+}
+
+// 获取照片中需要映射的字段映射前后的值.
+func GetDisplayNames(exif exiftool.FileMetadata) []DisplayName {
+	rules := getDisplayNameRules()
+	fields := slices.Clone(defaultDisplayNameFields)
+	for _, rule := range rules {
+		if rule.Field != "" && !slices.Contains(fields, rule.Field) {
+			fields = append(fields, rule.Field)
+		}
+	}
+	exifMake := strings.ToLower(pkg.AnyToString(exif.Fields["Make"]))
+	list := make([]DisplayName, 0, len(fields))
+	for _, field := range fields {
+		raw := pkg.AnyToString(exif.Fields[field])
+		list = append(list, DisplayName{
+			Field:      field,
+			Raw:        raw,
+			Normalized: normalizeDisplayName(rules, exifMake, field, raw),
+		})
+	}
+
+	return list
+}
+
+// 返回名称映射之后的exif信息,没有发生变化时直接返回原exif信息,不会修改原exif信息.
+func NormalizeDisplayNames(exif exiftool.FileMetadata) exiftool.FileMetadata {
+	rules := getDisplayNameRules()
+	if len(rules) == 0 {
+		return exif
+	}
+	var fields map[string]any
+	for _, name := range GetDisplayNames(exif) {
+		if name.Raw == name.Normalized {
+			continue
+		}
+		// exif信息可能来自缓存,复制一份之后再修改
+		if fields == nil {
+			fields = maps.Clone(exif.Fields)
+		}
+		fields[name.Field] = name.Normalized
+	}
+	if fields == nil {
+		return exif
+	}
+	exif.Fields = fields
+
+	return exif
+}
+
+// 依次执行对当前厂商与字段生效的规则.
+func normalizeDisplayName(rules []DisplayNameRule, exifMake, field, value string) string {
+	if value == "" {
+		return value
+	}
+	for _, rule := range rules {
+		if rule.Make != "" && !strings.Contains(exifMake, rule.Make) {
+			continue
+		}
+		if rule.Field != field && (rule.Field != "" || !slices.Contains(defaultDisplayNameFields, field)) {
+			continue
+		}
+		value = rule.regexp.ReplaceAllString(value, rule.Replace)
+	}
+
+	return value
+}
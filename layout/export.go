@@ -0,0 +1,163 @@
+package layout
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"WaterMark/pkg"
+)
+
+const (
+	// 导出格式:jpg.
+	EXPORT_FORMAT_JPG = "jpg"
+
+	// 导出格式:png.
+	EXPORT_FORMAT_PNG = "png"
+
+	// 导出格式:tiff.
+	EXPORT_FORMAT_TIFF = "tiff"
+
+	// 导出格式:bmp.
+	EXPORT_FORMAT_BMP = "bmp"
+
+	// jpg默认导出质量.
+	EXPORT_DEFAULT_JPEG_QUALITY = 100
+
+	// png压缩级别:默认.
+	PNG_COMPRESSION_DEFAULT = "default"
+
+	// png压缩级别:不压缩.
+	PNG_COMPRESSION_NONE = "none"
+
+	// png压缩级别:最快速度.
+	PNG_COMPRESSION_SPEED = "speed"
+
+	// png压缩级别:最高压缩.
+	PNG_COMPRESSION_BEST = "best"
+
+	// tiff压缩方式:不压缩.
+	TIFF_COMPRESSION_NONE = "none"
+
+	// tiff压缩方式:deflate无损压缩.
+	TIFF_COMPRESSION_DEFLATE = "deflate"
+
+	// 元数据:保留全部exif,xmp信息.
+	METADATA_ALL = "all"
+
+	// 元数据:不保留.
+	METADATA_NONE = "none"
+
+	// 元数据:保留除gps之外的全部信息.
+	METADATA_NO_GPS = "no_gps"
+)
+
+// 导出选项.
+type ExportOption struct {
+	// 导出格式,为空时按照原照片的后缀名选择.
+	Format string `json:"format"`
+	// png压缩级别.
+	PngCompression string `json:"png_compression"`
+	// tiff压缩方式.
+	TiffCompression string `json:"tiff_compression"`
+	// 元数据保留方式:all,none,no_gps.
+	Metadata string `json:"metadata"`
+	// jpg导出质量,1-100.
+	JpegQuality int `json:"jpeg_quality"`
+}
+
+// 后缀名与导出格式的对应关系.
+var exportFormatExts = map[string]string{
+	".jpg":  EXPORT_FORMAT_JPG,
+	".jpeg": EXPORT_FORMAT_JPG,
+	".png":  EXPORT_FORMAT_PNG,
+	".tif":  EXPORT_FORMAT_TIFF,
+	".tiff": EXPORT_FORMAT_TIFF,
+	".bmp":  EXPORT_FORMAT_BMP,
+}
+
+// 根据导出选项的JSON字符串构造导出选项,字符串为空时使用默认选项.
+func BuildExportOption(exportStr string) (ExportOption, pkg.EError) {
+	var opt ExportOption
+	if exportStr != "" {
+		if err := json.Unmarshal([]byte(exportStr), &opt); err != nil {
+			return opt, pkg.NewErrors(pkg.REQUEST_PARAM_ERROR, "导出选项格式错误,json解析失败:"+err.Error())
+		}
+	}
+
+	return opt, opt.Normalize()
+}
+
+// 检查导出选项并补全默认值.
+func (opt *ExportOption) Normalize() pkg.EError {
+	opt.Format = strings.ToLower(strings.TrimPrefix(opt.Format, "."))
+	if ext, ok := exportFormatExts["."+opt.Format]; ok {
+		opt.Format = ext
+	} else if opt.Format != "" {
+		return pkg.NewErrors(pkg.REQUEST_PARAM_ERROR, "不支持的导出格式:"+opt.Format)
+	}
+	if opt.JpegQuality == 0 {
+		opt.JpegQuality = EXPORT_DEFAULT_JPEG_QUALITY
+	}
+	if opt.JpegQuality < 1 || opt.JpegQuality > 100 {
+		return pkg.NewErrors(pkg.REQUEST_PARAM_ERROR, "jpg导出质量需要在1-100之间:"+strconv.Itoa(opt.JpegQuality))
+	}
+	if opt.PngCompression == "" {
+		opt.PngCompression = PNG_COMPRESSION_DEFAULT
+	}
+	switch opt.PngCompression {
+	case PNG_COMPRESSION_DEFAULT, PNG_COMPRESSION_NONE, PNG_COMPRESSION_SPEED, PNG_COMPRESSION_BEST:
+	default:
+		return pkg.NewErrors(pkg.REQUEST_PARAM_ERROR, "不支持的png压缩级别:"+opt.PngCompression)
+	}
+	if opt.TiffCompression == "" {
+		opt.TiffCompression = TIFF_COMPRESSION_DEFLATE
+	}
+	if opt.TiffCompression != TIFF_COMPRESSION_NONE && opt.TiffCompression != TIFF_COMPRESSION_DEFLATE {
+		return pkg.NewErrors(pkg.REQUEST_PARAM_ERROR, "不支持的tiff压缩方式:"+opt.TiffCompression)
+	}
+
+	return opt.normalizeMetadata()
+}
+
+// 检查元数据保留方式,默认保留全部.
+func (opt *ExportOption) normalizeMetadata() pkg.EError {
+	if opt.Metadata == "" {
+		opt.Metadata = METADATA_ALL
+	}
+	switch opt.Metadata {
+	case METADATA_ALL, METADATA_NONE, METADATA_NO_GPS:
+		return pkg.NoError
+	default:
+		return pkg.NewErrors(pkg.REQUEST_PARAM_ERROR, "不支持的元数据保留方式:"+opt.Metadata)
+	}
+}
+
+// 是否需要拷贝原照片的元数据,exiftool不支持写入bmp图片.
+func (opt *ExportOption) NeedCopyMetadata(path string) bool {
+	return opt.Metadata != METADATA_NONE && opt.GetFormat(path) != EXPORT_FORMAT_BMP
+}
+
+// 获取保存文件时使用的格式,没有指定格式时按照文件后缀名选择,不支持导出的后缀(webp,heic等)使用jpg.
+func (opt *ExportOption) GetFormat(path string) string {
+	if opt.Format != "" {
+		return opt.Format
+	}
+	if format, ok := exportFormatExts[strings.ToLower(filepath.Ext(path))]; ok {
+		return format
+	}
+
+	return EXPORT_FORMAT_JPG
+}
+
+// 获取导出文件名,后缀名与导出格式不一致时替换文件的后缀名.
+func (opt *ExportOption) GetSaveFileName(name string) string {
+	format := opt.GetFormat(name)
+	ext := filepath.Ext(name)
+	if exportFormatExts[strings.ToLower(ext)] == format {
+		return name
+	}
+
+	return strings.TrimSuffix(name, ext) + "." + format
+}
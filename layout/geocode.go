@@ -0,0 +1,431 @@
+package layout
+
+import (
+	"bufio"
+	"encoding/csv"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/yijianlingcheng/go-exiftool"
+
+	"WaterMark/internal"
+	"WaterMark/pkg"
+)
+
+const (
+	// exif中的GPS字段.
+	GPS_POSITION_FIELD = "GPSPosition"
+
+	// 城市字段.
+	PLACE_CITY = "City"
+
+	// 省份字段.
+	PLACE_PROVINCE = "Province"
+
+	// 国家字段.
+	PLACE_COUNTRY = "Country"
+
+	// 地球平均半径,单位为公里.
+	EARTH_RADIUS = 6371.0
+
+	// GeoNames城市文件中的列:编号,名称,纬度,经度,国家代码,一级行政区代码.
+	GEONAMES_ID        = 0
+	GEONAMES_NAME      = 1
+	GEONAMES_LATITUDE  = 4
+	GEONAMES_LONGITUDE = 5
+	GEONAMES_COUNTRY   = 8
+	GEONAMES_ADMIN1    = 10
+
+	// GeoNames别名文件中的列:编号,语言,名称,是否为首选名称,是否为简称,是否为俗称,是否为历史名称.
+	GEONAMES_ALTERNATE_ID         = 1
+	GEONAMES_ALTERNATE_LANGUAGE   = 2
+	GEONAMES_ALTERNATE_NAME       = 3
+	GEONAMES_ALTERNATE_PREFERRED  = 4
+	GEONAMES_ALTERNATE_SHORT      = 5
+	GEONAMES_ALTERNATE_COLLOQUIAL = 6
+	GEONAMES_ALTERNATE_HISTORIC   = 7
+)
+
+type (
+	// 离线地名数据库中的地点.
+	Place struct {
+		City     string `json:"city"`
+		Province string `json:"province"`
+		Country  string `json:"country"`
+		// 与照片拍摄位置之间的距离,单位为公里.
+		Distance  float64 `json:"distance"`
+		Latitude  float64 `json:"latitude"`
+		Longitude float64 `json:"longitude"`
+	}
+
+	// GeoNames中的地名与编号,编号用于查找其它语言的名称.
+	geoNamesName struct {
+		id   string
+		name string
+	}
+
+	// GeoNames城市文件中的城市,省份与国家使用代码保存,读取别名之后再替换为名称.
+	geoNamesCity struct {
+		id      string
+		admin1  string
+		country string
+		place   Place
+	}
+
+	// GeoNames别名文件中的名称与优先级.
+	geoNamesAlternate struct {
+		name  string
+		score int
+	}
+)
+
+var (
+	// 已经加载的地名数据库,为nil时表示没有加载.
+	places *placeTree
+
+	// 读写地名数据库使用的锁.
+	placesMtx sync.RWMutex
+
+	// 地名字段,按照展示的顺序排列.
+	placeFields = []string{PLACE_CITY, PLACE_PROVINCE, PLACE_COUNTRY}
+
+	// GeoNames别名文件,按照顺序查找,alternateNames.txt为旧版本的文件名.
+	geoNamesAlternateFiles = []string{"alternateNamesV2.txt", "alternateNames.txt"}
+)
+
+// 重新加载离线地名数据库.
+func ReloadPlaces() pkg.EError {
+	tree, err := loadPlaces()
+	if pkg.HasError(err) {
+		return err
+	}
+	placesMtx.Lock()
+	places = tree
+	placesMtx.Unlock()
+
+	return pkg.NoError
+}
+
+// 清除已经加载的地名数据库,下次使用时重新加载.
+func clearPlaces() {
+	placesMtx.Lock()
+	places = nil
+	placesMtx.Unlock()
+}
+
+// 获取地名数据库,没有加载时先加载,加载失败时不做反向地理编码.
+func getPlaces() *placeTree {
+	placesMtx.RLock()
+	tree := places
+	placesMtx.RUnlock()
+	if tree != nil {
+		return tree
+	}
+	if err := ReloadPlaces(); pkg.HasError(err) {
+		internal.Log.Error(err.String())
+	}
+	placesMtx.Lock()
+	defer placesMtx.Unlock()
+	if places == nil {
+		places = newPlaceTree(nil)
+	}
+
+	return places
+}
+
+// 按照GPS信息查找最近的地点,超出配置的距离时返回false.
+func ReverseGeocode(gps string) (Place, bool) {
+	lat, lon, ok := GpsDecimal(gps)
+	if !ok {
+		return Place{}, false
+	}
+	place, dist, ok := getPlaces().nearest(lat, lon)
+	if !ok || dist > internal.GetPlaceRadius() {
+		return Place{}, false
+	}
+	place.Distance = dist
+
+	return place, true
+}
+
+// 按照GPS信息补充城市,省份与国家字段,照片中已经存在的字段不会被覆盖,不会修改原exif信息.
+func AddPlaceFields(exif exiftool.FileMetadata) exiftool.FileMetadata {
+	gps := pkg.AnyToString(exif.Fields[GPS_POSITION_FIELD])
+	if gps == "" {
+		return exif
+	}
+	place, ok := ReverseGeocode(gps)
+	if !ok {
+		return exif
+	}
+	// exif信息可能来自缓存,复制一份之后再修改
+	fields := maps.Clone(exif.Fields)
+	for i, value := range []string{place.City, place.Province, place.Country} {
+		if value != "" && pkg.AnyToString(fields[placeFields[i]]) == "" {
+			fields[placeFields[i]] = value
+		}
+	}
+	exif.Fields = fields
+
+	return exif
+}
+
+// 是否是地名字段.
+func IsPlaceField(field string) bool {
+	return slices.Contains(placeFields, field)
+}
+
+// 读取地名数据库,txt文件按照GeoNames格式读取,其它文件按照csv格式读取,文件不存在时返回空的数据库.
+func loadPlaces() (*placeTree, pkg.EError) {
+	file := internal.GetPlaceDatabasePath()
+	if !internal.PathExists(file) {
+		return newPlaceTree(nil), pkg.NoError
+	}
+	var items []placeItem
+	var err pkg.EError
+	if strings.EqualFold(filepath.Ext(file), ".txt") {
+		items, err = loadGeoNamesPlaces(file, internal.GetPlaceLanguage())
+	} else {
+		items, err = loadCSVPlaces(file, internal.GetPlaceLanguage())
+	}
+	if pkg.HasError(err) {
+		return nil, err
+	}
+
+	return newPlaceTree(items), pkg.NoError
+}
+
+// 读取csv格式的地名数据库,第一行为列名,city_zh等带有语言后缀的列优先于city等默认列.
+func loadCSVPlaces(file, language string) ([]placeItem, pkg.EError) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, pkg.NewErrors(pkg.FILE_NOT_READ_ERROR, file+":地名数据库打开失败")
+	}
+	defer f.Close()
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	columns, colErr := getCSVPlaceColumns(reader, file)
+	if pkg.HasError(colErr) {
+		return nil, colErr
+	}
+	items := make([]placeItem, 0)
+	for {
+		record, readErr := reader.Read()
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return nil, pkg.NewErrors(pkg.LAYOUT_PLACE_DATABASE_ERROR, file+":地名数据库解析失败:"+readErr.Error())
+		}
+		if item, ok := newCSVPlaceItem(record, columns, language); ok {
+			items = append(items, item)
+		}
+	}
+
+	return items, pkg.NoError
+}
+
+// 读取csv的列名,列名不区分大小写,必须包含latitude与longitude列.
+func getCSVPlaceColumns(reader *csv.Reader, file string) (map[string]int, pkg.EError) {
+	header, err := reader.Read()
+	if err != nil {
+		return nil, pkg.NewErrors(pkg.LAYOUT_PLACE_DATABASE_ERROR, file+":地名数据库没有列名")
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"latitude", "longitude"} {
+		if _, ok := columns[name]; !ok {
+			return nil, pkg.NewErrors(pkg.LAYOUT_PLACE_DATABASE_ERROR, file+":地名数据库缺少"+name+"列")
+		}
+	}
+
+	return columns, pkg.NoError
+}
+
+// 按照列名读取一行地点,经纬度错误时跳过.
+func newCSVPlaceItem(record []string, columns map[string]int, language string) (placeItem, bool) {
+	value := func(name string) string {
+		if i, ok := columns[name+"_"+language]; ok && i < len(record) && strings.TrimSpace(record[i]) != "" {
+			return strings.TrimSpace(record[i])
+		}
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+
+		return ""
+	}
+	place, ok := newPlace(value("latitude"), value("longitude"))
+	if !ok {
+		return placeItem{}, false
+	}
+	place.City = value("city")
+	place.Province = value("province")
+	place.Country = value("country")
+
+	return placeItem{place: place, point: getSpherePoint(place.Latitude, place.Longitude)}, true
+}
+
+// 读取GeoNames格式的城市文件,同一文件夹中存在admin1CodesASCII.txt与countryInfo.txt时使用其中的省份与国家名称
+// 存在alternateNamesV2.txt时按照语言替换城市,省份与国家名称,没有对应语言的名称时使用英文名称.
+func loadGeoNamesPlaces(file, language string) ([]placeItem, pkg.EError) {
+	dir := filepath.Dir(file)
+	countries := readGeoNamesNames(filepath.Join(dir, "countryInfo.txt"), 0, 4, 16)
+	provinces := readGeoNamesNames(filepath.Join(dir, "admin1CodesASCII.txt"), 0, 1, 3)
+	cities := make([]geoNamesCity, 0)
+	err := readTabLines(file, "", func(record []string) {
+		if len(record) <= GEONAMES_ADMIN1 {
+			return
+		}
+		place, ok := newPlace(record[GEONAMES_LATITUDE], record[GEONAMES_LONGITUDE])
+		if !ok {
+			return
+		}
+		place.City = record[GEONAMES_NAME]
+		cities = append(cities, geoNamesCity{
+			id:      record[GEONAMES_ID],
+			admin1:  record[GEONAMES_COUNTRY] + "." + record[GEONAMES_ADMIN1],
+			country: record[GEONAMES_COUNTRY],
+			place:   place,
+		})
+	})
+	if err != nil {
+		return nil, pkg.NewErrors(pkg.FILE_NOT_READ_ERROR, file+":地名数据库读取失败:"+err.Error())
+	}
+	localizeGeoNames(dir, language, cities, countries, provinces)
+	items := make([]placeItem, 0, len(cities))
+	for _, city := range cities {
+		place := city.place
+		place.Province = provinces[city.admin1].name
+		place.Country = countries[city.country].name
+		if place.Country == "" {
+			place.Country = city.country
+		}
+		items = append(items, placeItem{place: place, point: getSpherePoint(place.Latitude, place.Longitude)})
+	}
+
+	return items, pkg.NoError
+}
+
+// 读取GeoNames中代码与名称,编号的对应关系,文件不存在时返回空.
+func readGeoNamesNames(file string, codeIndex, nameIndex, idIndex int) map[string]geoNamesName {
+	names := make(map[string]geoNamesName)
+	if !internal.PathExists(file) {
+		return names
+	}
+	err := readTabLines(file, "", func(record []string) {
+		if len(record) > max(codeIndex, nameIndex, idIndex) {
+			names[record[codeIndex]] = geoNamesName{id: record[idIndex], name: record[nameIndex]}
+		}
+	})
+	if err != nil {
+		internal.Log.Error(file + ":GeoNames文件读取失败:" + err.Error())
+	}
+
+	return names
+}
+
+// 使用GeoNames别名文件中对应语言的名称替换城市,省份与国家名称,没有别名文件时保留原名称.
+func localizeGeoNames(dir, language string, cities []geoNamesCity, names ...map[string]geoNamesName) {
+	file := ""
+	for _, name := range geoNamesAlternateFiles {
+		if internal.PathExists(filepath.Join(dir, name)) {
+			file = filepath.Join(dir, name)
+
+			break
+		}
+	}
+	if file == "" || language == "" {
+		return
+	}
+	ids := make(map[string]bool, len(cities))
+	for _, city := range cities {
+		ids[city.id] = true
+	}
+	for _, items := range names {
+		for _, item := range items {
+			ids[item.id] = true
+		}
+	}
+	alternates := readGeoNamesAlternateNames(file, language, ids)
+	for i := range cities {
+		if alternate, ok := alternates[cities[i].id]; ok {
+			cities[i].place.City = alternate.name
+		}
+	}
+	for _, items := range names {
+		for code, item := range items {
+			if alternate, ok := alternates[item.id]; ok {
+				items[code] = geoNamesName{id: item.id, name: alternate.name}
+			}
+		}
+	}
+}
+
+// 读取别名文件中指定编号与语言的名称,跳过俗称与历史名称,首选名称优先,其次为简称.
+func readGeoNamesAlternateNames(file, language string, ids map[string]bool) map[string]geoNamesAlternate {
+	alternates := make(map[string]geoNamesAlternate)
+	// 别名文件很大,先按照语言过滤之后再拆分
+	err := readTabLines(file, "\t"+language+"\t", func(record []string) {
+		if len(record) <= GEONAMES_ALTERNATE_HISTORIC || !ids[record[GEONAMES_ALTERNATE_ID]] ||
+			record[GEONAMES_ALTERNATE_LANGUAGE] != language ||
+			record[GEONAMES_ALTERNATE_COLLOQUIAL] == "1" || record[GEONAMES_ALTERNATE_HISTORIC] == "1" {
+			return
+		}
+		score := 0
+		if record[GEONAMES_ALTERNATE_PREFERRED] == "1" {
+			score += 2
+		}
+		if record[GEONAMES_ALTERNATE_SHORT] == "1" {
+			score++
+		}
+		id := record[GEONAMES_ALTERNATE_ID]
+		if current, ok := alternates[id]; !ok || score > current.score {
+			alternates[id] = geoNamesAlternate{name: record[GEONAMES_ALTERNATE_NAME], score: score}
+		}
+	})
+	if err != nil {
+		internal.Log.Error(file + ":GeoNames别名文件读取失败:" + err.Error())
+	}
+
+	return alternates
+}
+
+// 按行读取使用tab分割的文件,跳过空行与#开头的注释,filter不为空时只处理包含filter的行.
+func readTabLines(file, filter string, fn func(record []string)) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	// GeoNames中的别名列可能很长
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") || (filter != "" && !strings.Contains(line, filter)) {
+			continue
+		}
+		fn(strings.Split(line, "\t"))
+	}
+
+	return scanner.Err()
+}
+
+// 解析地点的经纬度,超出范围时返回false.
+func newPlace(latitude, longitude string) (Place, bool) {
+	lat, latErr := strconv.ParseFloat(strings.TrimSpace(latitude), 64)
+	lon, lonErr := strconv.ParseFloat(strings.TrimSpace(longitude), 64)
+	if latErr != nil || lonErr != nil || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return Place{}, false
+	}
+
+	return Place{Latitude: lat, Longitude: lon}, true
+}
@@ -0,0 +1,101 @@
+package layout
+
+import (
+	"cmp"
+	"math"
+	"slices"
+)
+
+type (
+	// 地点与地点在单位球面上的三维坐标.
+	placeItem struct {
+		place Place
+		point [3]float64
+	}
+
+	// k-d树,按照三维坐标排列之后保存在数组中,每一段的中间位置为当前节点.
+	placeTree struct {
+		items []placeItem
+	}
+
+	// 最近地点的查找状态.
+	placeSearch struct {
+		best     *placeItem
+		point    [3]float64
+		bestDist float64
+	}
+)
+
+// 创建k-d树,会修改items的顺序.
+func newPlaceTree(items []placeItem) *placeTree {
+	buildPlaceTree(items, 0)
+
+	return &placeTree{items: items}
+}
+
+// 按照当前层的坐标轴排序,中间位置的地点作为节点,两边分别递归.
+func buildPlaceTree(items []placeItem, depth int) {
+	if len(items) <= 1 {
+		return
+	}
+	axis := depth % 3
+	slices.SortFunc(items, func(a, b placeItem) int {
+		return cmp.Compare(a.point[axis], b.point[axis])
+	})
+	mid := len(items) / 2
+	buildPlaceTree(items[:mid], depth+1)
+	buildPlaceTree(items[mid+1:], depth+1)
+}
+
+// 查找距离最近的地点,返回地点与地面距离(公里).
+func (t *placeTree) nearest(lat, lon float64) (Place, float64, bool) {
+	if len(t.items) == 0 {
+		return Place{}, 0, false
+	}
+	s := &placeSearch{point: getSpherePoint(lat, lon), bestDist: math.Inf(1)}
+	s.search(t.items, 0)
+
+	// 三维坐标之间为弦长,换算为球面距离
+	return s.best.place, 2 * EARTH_RADIUS * math.Asin(min(1, math.Sqrt(s.bestDist)/2)), true
+}
+
+// 先查找目标所在的一边,另一边只有可能存在更近的地点时才查找.
+func (s *placeSearch) search(items []placeItem, depth int) {
+	if len(items) == 0 {
+		return
+	}
+	mid := len(items) / 2
+	item := &items[mid]
+	if dist := getSquaredDistance(item.point, s.point); dist < s.bestDist {
+		s.best, s.bestDist = item, dist
+	}
+	axis := depth % 3
+	diff := s.point[axis] - item.point[axis]
+	near, far := items[:mid], items[mid+1:]
+	if diff > 0 {
+		near, far = far, near
+	}
+	s.search(near, depth+1)
+	if diff*diff < s.bestDist {
+		s.search(far, depth+1)
+	}
+}
+
+// 经纬度转换为单位球面上的三维坐标,坐标之间的直线距离与球面距离单调对应.
+func getSpherePoint(lat, lon float64) [3]float64 {
+	latRad := lat * math.Pi / 180
+	lonRad := lon * math.Pi / 180
+
+	return [3]float64{
+		math.Cos(latRad) * math.Cos(lonRad),
+		math.Cos(latRad) * math.Sin(lonRad),
+		math.Sin(latRad),
+	}
+}
+
+// 两个坐标之间距离的平方.
+func getSquaredDistance(a, b [3]float64) float64 {
+	x, y, z := a[0]-b[0], a[1]-b[1], a[2]-b[2]
+
+	return x*x + y*y + z*z
+}
@@ -7,6 +7,9 @@ import (
 	"strings"
 )
 
+// 正则表达式匹配格式：数字 deg 数字 ' 数字 " 方向.
+var gpsRegexp = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*deg\s*(\d+(?:\.\d+)?)\s*'\s*(\d+(?:\.\d+)?)\s*"\s*([NSEW])`)
+
 // 获取GPS格式化之后的字符串.
 func GpsFormat(gps string) string {
 	if gps == "" {
@@ -27,14 +30,51 @@ func GetGPSOrDefault(gps, other string) string {
 	return other
 }
 
+// 获取GPS对应的geo URI,例如geo:35.689500,139.691700,解析失败时返回空字符串.
+func GpsGeoURI(gps string) string {
+	lat, lon, ok := GpsDecimal(gps)
+	if !ok {
+		return ""
+	}
+
+	return fmt.Sprintf("geo:%.6f,%.6f", lat, lon)
+}
+
+// 将GPS信息转换为十进制的纬度与经度,南纬与西经为负数.
+func GpsDecimal(gps string) (float64, float64, bool) {
+	str := strings.Split(gps, ", ")
+	if len(str) != 2 {
+		return 0, 0, false
+	}
+	lat, latOk := parseGPSDecimal(str[0])
+	lon, lonOk := parseGPSDecimal(str[1])
+
+	return lat, lon, latOk && lonOk
+}
+
+// 将度分秒格式的GPS信息转换为十进制.
+func parseGPSDecimal(str string) (float64, bool) {
+	matches := gpsRegexp.FindStringSubmatch(str)
+	if matches == nil {
+		return 0, false
+	}
+	degrees, _ := strconv.ParseFloat(matches[1], 64)
+	minutes, _ := strconv.ParseFloat(matches[2], 64)
+	seconds, _ := strconv.ParseFloat(matches[3], 64)
+	value := degrees + minutes/60 + seconds/3600
+	if matches[4] == "S" || matches[4] == "W" {
+		value = -value
+	}
+
+	return value, true
+}
+
 // 解析GPS信息.
 func parseGPSInfo(str string) string {
 	if str == "" {
 		return ""
 	}
-	// 正则表达式匹配格式：数字 deg 数字 ' 数字 " 方向
-	re := regexp.MustCompile(`(\d+(?:\.\d+)?)\s*deg\s*(\d+(?:\.\d+)?)\s*'\s*(\d+(?:\.\d+)?)\s*"\s*([NSEW])`)
-	matches := re.FindStringSubmatch(str)
+	matches := gpsRegexp.FindStringSubmatch(str)
 
 	if matches == nil {
 		return ""
@@ -3,10 +3,13 @@ package layout
 import (
 	"fmt"
 	"image"
+	"image/color"
 	"path/filepath"
 	"strings"
 	"sync"
 
+	"github.com/yijianlingcheng/go-exiftool"
+
 	"WaterMark/internal"
 	"WaterMark/pkg"
 )
@@ -23,8 +26,11 @@ type (
 		Name      string
 		Ext       string
 		LogoPath  string
+		svgData   []byte
 		Width     int
 		Height    int
+		// 不透明部分的平均相对亮度,用于判断logo在背景上是否清晰.
+		Luminance float64
 		IsLoad    bool
 	}
 )
@@ -40,20 +46,9 @@ var (
 	UNSUPPORT_LOGO = "unsupported_logo"
 )
 
-// 根据exif信息中的model字段获取logo名称.
+// 根据exif信息中的Make字段获取logo名称,只有Make信息时使用,匹配方式与GetLogoNameByExif相同.
 func GetLogoNameByMake(name string) string {
-	// 转换成全小写比较
-	name = strings.ToLower(name)
-	if _, ok := logos.logoMap[name]; ok {
-		return name
-	}
-	for key := range logos.logoMap {
-		if strings.Contains(name, key) {
-			return key
-		}
-	}
-
-	return UNSUPPORT_LOGO
+	return GetLogoNameByExif(exiftool.FileMetadata{Fields: map[string]any{"Make": name}})
 }
 
 // 检查logo名称是否不支持.
@@ -88,37 +83,84 @@ func GetLogoXAndYByNameAndHeight(name string, height int) map[string]int {
 
 // 根据logo名称,宽高获取logo信息.
 func GetLogoImageByNameAndWidhtAndHeight(name string, width, height int) (*Logo, pkg.EError) {
-	// 构造新的logo文件名称
-	newLogoName := fmt.Sprintf("%d_%d_%s", width, height, name)
+	return GetColorLogoImageByNameAndWidhtAndHeight(name, width, height, nil)
+}
 
-	logoItem, err := GetLogoImageByName(newLogoName)
-	if !pkg.HasError(err) {
-		return logoItem, err
+// 根据logo名称,宽高与颜色获取logo信息
+// svg格式的logo按照宽高直接重新绘制,指定颜色时重新着色,其它格式的logo缩放生成并且忽略颜色.
+func GetColorLogoImageByNameAndWidhtAndHeight(name string, width, height int, fill color.Color) (*Logo, pkg.EError) {
+	originLogo, findErr := GetLogoImageByName(name)
+	if pkg.HasError(findErr) {
+		return &Logo{}, pkg.ImageLogoNotFindError
 	}
+	if originLogo.svgData == nil {
+		fill = nil
+	}
+
+	return getSizedLogoImage(originLogo, name, width, height, fill)
+}
 
+// 根据logo名称,宽高与颜色获取重新着色之后的logo信息,所有格式的logo都会重新着色.
+func GetTintLogoImageByNameAndWidhtAndHeight(name string, width, height int, fill color.Color) (*Logo, pkg.EError) {
 	originLogo, findErr := GetLogoImageByName(name)
 	if pkg.HasError(findErr) {
 		return &Logo{}, pkg.ImageLogoNotFindError
 	}
 
+	return getSizedLogoImage(originLogo, name, width, height, fill)
+}
+
+// 获取指定宽高与颜色的logo,没有生成过时生成并缓存.
+func getSizedLogoImage(originLogo *Logo, name string, width, height int, fill color.Color) (*Logo, pkg.EError) {
+	// 构造新的logo文件名称
+	newLogoName := fmt.Sprintf("%d_%d_%s", width, height, getColorLogoName(name, fill))
+
+	logoItem, err := GetLogoImageByName(newLogoName)
+	if !pkg.HasError(err) {
+		return logoItem, err
+	}
+
 	// 如果上面没找到,说明需要根据尺寸重新生成一个logo并加载进来
 	// 加锁防止并发写
 	writeLogosMapMtx.Lock()
 	defer writeLogosMapMtx.Unlock()
 
 	// 生成指定的新图片
-	newLogoImage := pkg.GenerateImageByWidthHeight(originLogo.LogoImage, width, height)
+	newLogoImage, genErr := generateLogoImage(originLogo, width, height, fill)
+	if pkg.HasError(genErr) {
+		return &Logo{}, genErr
+	}
 	// 写入logos中
 	logos.logoMap[newLogoName] = newLogoWithImage(newLogoName, newLogoImage)
 
 	return logos.logoMap[newLogoName], pkg.NoError
 }
 
+// 按照宽高生成新的logo图片,svg格式的logo重新绘制,其它格式的logo缩放生成.
+func generateLogoImage(logo *Logo, width, height int, fill color.Color) (image.Image, pkg.EError) {
+	if logo.svgData != nil {
+		return generateSvgLogoImage(logo, width, height, fill)
+	}
+	img := pkg.GenerateImageByWidthHeight(logo.LogoImage, width, height)
+	if fill == nil {
+		return img, pkg.NoError
+	}
+
+	return recolorLogoImage(img, fill), pkg.NoError
+}
+
 // 加载指定的logo图片,并返回一个logo结构体.
 func newLogo(name, fullPath string) (*Logo, pkg.EError) {
+	if isSvgLogoFile(fullPath) {
+		return newSvgLogo(name, fullPath)
+	}
 	name = strings.ToLower(name)
 	ext := filepath.Ext(fullPath)
-	imgaeDecode, loadErr := pkg.LoadImageWithDecode(fullPath)
+	imgaeDecode, loadErr := internal.LoadImageWithWorkingColorSpace(fullPath)
+	if pkg.HasError(loadErr) {
+		return &Logo{}, loadErr
+	}
+	luminance, _ := pkg.ImageLuminance(imgaeDecode, imgaeDecode.Bounds())
 
 	return &Logo{
 		IsLoad:    true,
@@ -127,8 +169,9 @@ func newLogo(name, fullPath string) (*Logo, pkg.EError) {
 		Name:      name,
 		Ext:       ext,
 		LogoPath:  fullPath,
+		Luminance: luminance,
 		LogoImage: imgaeDecode,
-	}, loadErr
+	}, pkg.NoError
 }
 
 // 返回一个logo结构体.
@@ -149,6 +192,10 @@ func LogosImagesInit() pkg.EError {
 	logos = &logosMaps{
 		logoMap: make(map[string]*Logo),
 	}
+	// logo匹配规则
+	if err := ReloadLogoRules(); pkg.HasError(err) {
+		return err
+	}
 	// 自动注册在结构体中
 	dir := internal.GetLogosPath("")
 	logoFiles, err := pkg.GetDirFiles(dir)
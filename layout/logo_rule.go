@@ -0,0 +1,367 @@
+package layout
+
+import (
+	"encoding/json"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/yijianlingcheng/go-exiftool"
+
+	"WaterMark/internal"
+	"WaterMark/pkg"
+)
+
+const (
+	// logo匹配来源:规则.
+	LOGO_SOURCE_RULE = "rule"
+
+	// logo匹配来源:厂商别名.
+	LOGO_SOURCE_ALIAS = "alias"
+
+	// logo匹配来源:厂商名称与logo文件名称相同.
+	LOGO_SOURCE_NAME = "name"
+
+	// logo匹配来源:厂商名称包含logo文件名称.
+	LOGO_SOURCE_CONTAINS = "contains"
+
+	// logo匹配来源:镜头型号包含logo文件名称.
+	LOGO_SOURCE_LENS_MODEL = "lens_model"
+)
+
+type (
+	// logo匹配规则文件.
+	logoRuleFile struct {
+		// 厂商别名,key为exif中的Make(不区分大小写),value为logo名称.
+		Aliases map[string]string `json:"aliases"`
+		// 镜头厂商别名,key为exif中的LensMake(不区分大小写),value为logo名称.
+		LensAliases map[string]string `json:"lens_aliases"`
+		List        []LogoRule        `json:"list"`
+	}
+
+	// logo匹配规则,按照优先级从高到低匹配,优先级相同时按照文件中的顺序匹配.
+	LogoRule struct {
+		makeRegexp     *regexp.Regexp
+		modelRegexp    *regexp.Regexp
+		lensMakeRegexp *regexp.Regexp
+		// 规则名称,用于展示匹配过程.
+		Name string `json:"name"`
+		// 匹配成功之后使用的logo名称.
+		Logo string `json:"logo"`
+		// 匹配exif中Make的正则表达式,不区分大小写,为空时不检查.
+		Make string `json:"make"`
+		// 匹配exif中Model的正则表达式.
+		Model string `json:"model"`
+		// 匹配exif中LensMake的正则表达式.
+		LensMake string `json:"lens_make"`
+		// 优先级,数值越大越先匹配.
+		Priority int `json:"priority"`
+	}
+
+	// logo匹配结果.
+	LogoResolution struct {
+		Make      string      `json:"make"`
+		Model     string      `json:"model"`
+		LensMake  string      `json:"lens_make"`
+		LensModel string      `json:"lens_model"`
+		Logo      string      `json:"logo"`
+		Trace     []LogoTrace `json:"trace"`
+	}
+
+	// logo匹配过程中的一步.
+	LogoTrace struct {
+		Source  string `json:"source"`
+		Rule    string `json:"rule"`
+		Logo    string `json:"logo"`
+		Reason  string `json:"reason"`
+		Matched bool   `json:"matched"`
+	}
+)
+
+var (
+	// 已经加载的logo匹配规则.
+	logoRules = newLogoRuleFile()
+
+	// 读写logo匹配规则使用的锁.
+	logoRulesMtx sync.RWMutex
+)
+
+// 重新加载logo匹配规则文件,文件不存在时只按照logo文件名称匹配.
+func ReloadLogoRules() pkg.EError {
+	rules, err := loadLogoRules()
+	if pkg.HasError(err) {
+		return err
+	}
+	logoRulesMtx.Lock()
+	logoRules = rules
+	logoRulesMtx.Unlock()
+
+	return pkg.NoError
+}
+
+// 空的logo匹配规则.
+func newLogoRuleFile() *logoRuleFile {
+	return &logoRuleFile{Aliases: map[string]string{}, LensAliases: map[string]string{}, List: []LogoRule{}}
+}
+
+// 读取并解析logo匹配规则文件.
+func loadLogoRules() (*logoRuleFile, pkg.EError) {
+	rules := newLogoRuleFile()
+	file := internal.GetLogoRulePath()
+	if !internal.PathExists(file) {
+		return rules, pkg.NoError
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return rules, pkg.NewErrors(pkg.FILE_NOT_READ_ERROR, file+":logo规则文件打开失败")
+	}
+	var ruleFile logoRuleFile
+	if err = json.Unmarshal(content, &ruleFile); err != nil {
+		return rules, pkg.NewErrors(pkg.FILE_NOT_READ_ERROR, file+":logo规则文件json解析失败")
+	}
+	for alias, logo := range ruleFile.Aliases {
+		rules.Aliases[strings.ToLower(strings.TrimSpace(alias))] = strings.ToLower(logo)
+	}
+	for alias, logo := range ruleFile.LensAliases {
+		rules.LensAliases[strings.ToLower(strings.TrimSpace(alias))] = strings.ToLower(logo)
+	}
+	for _, rule := range ruleFile.List {
+		if err := rule.compile(); pkg.HasError(err) {
+			return rules, err
+		}
+		rules.List = append(rules.List, rule)
+	}
+	sort.SliceStable(rules.List, func(i, j int) bool {
+		return rules.List[i].Priority > rules.List[j].Priority
+	})
+
+	return rules, pkg.NoError
+}
+
+// 编译规则中的正则表达式.
+func (rule *LogoRule) compile() pkg.EError {
+	rule.Logo = strings.ToLower(rule.Logo)
+	patterns := []struct {
+		target  **regexp.Regexp
+		pattern string
+	}{
+		{&rule.makeRegexp, rule.Make},
+		{&rule.modelRegexp, rule.Model},
+		{&rule.lensMakeRegexp, rule.LensMake},
+	}
+	for _, item := range patterns {
+		if item.pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile("(?i)" + item.pattern)
+		if err != nil {
+			return pkg.NewErrors(pkg.IMAGE_LOGO_RULE_ERROR, rule.Name+":logo规则正则表达式错误:"+err.Error())
+		}
+		*item.target = re
+	}
+
+	return pkg.NoError
+}
+
+// 检查规则是否匹配,返回不匹配的原因.
+func (rule *LogoRule) match(resolution *LogoResolution) (bool, string) {
+	checks := []struct {
+		re    *regexp.Regexp
+		name  string
+		value string
+	}{
+		{rule.makeRegexp, "Make", resolution.Make},
+		{rule.modelRegexp, "Model", resolution.Model},
+		{rule.lensMakeRegexp, "LensMake", resolution.LensMake},
+	}
+	for _, check := range checks {
+		if check.re != nil && !check.re.MatchString(check.value) {
+			return false, check.name + "不匹配:" + check.re.String()
+		}
+	}
+
+	return true, ""
+}
+
+// 根据照片的exif信息获取logo名称.
+func GetLogoNameByExif(exif exiftool.FileMetadata) string {
+	return ResolveLogo(exif).Logo
+}
+
+// 根据照片的exif信息获取logo名称以及匹配过程
+// 依次使用:匹配规则,厂商别名,与厂商名称相同的logo,厂商名称中的完整单词与logo名称相同(名称越长越优先).
+func ResolveLogo(exif exiftool.FileMetadata) LogoResolution {
+	resolution := newLogoResolution(exif)
+	logoRulesMtx.RLock()
+	rules := logoRules
+	logoRulesMtx.RUnlock()
+
+	for i := range rules.List {
+		rule := &rules.List[i]
+		matched, reason := rule.match(&resolution)
+		if resolution.accept(LOGO_SOURCE_RULE, rule.Name, rule.Logo, matched, reason) {
+			return resolution
+		}
+	}
+	if resolution.resolveByAlias(rules.Aliases, resolution.Make) {
+		return resolution
+	}
+	if resolution.resolveByName(LOGO_SOURCE_CONTAINS, resolution.Make) {
+		return resolution
+	}
+	resolution.reject(LOGO_SOURCE_CONTAINS, resolution.Make, "没有找到厂商对应的logo文件")
+
+	return resolution
+}
+
+// 根据照片的exif信息获取镜头厂商logo名称以及匹配过程
+// 依次使用:镜头厂商别名,镜头厂商名称,镜头型号中的完整单词,与相机logo相同时不使用.
+func ResolveLensLogo(exif exiftool.FileMetadata) LogoResolution {
+	resolution := newLogoResolution(exif)
+	logoRulesMtx.RLock()
+	rules := logoRules
+	logoRulesMtx.RUnlock()
+
+	if !resolution.resolveByAlias(rules.LensAliases, resolution.LensMake) &&
+		!resolution.resolveByName(LOGO_SOURCE_CONTAINS, resolution.LensMake) &&
+		!resolution.resolveByName(LOGO_SOURCE_LENS_MODEL, resolution.LensModel) {
+		resolution.reject(LOGO_SOURCE_LENS_MODEL, resolution.LensModel, "没有找到镜头厂商对应的logo文件")
+
+		return resolution
+	}
+	if cameraLogo := GetLogoNameByExif(exif); cameraLogo == resolution.Logo {
+		resolution.Logo = UNSUPPORT_LOGO
+		resolution.reject(LOGO_SOURCE_CONTAINS, cameraLogo, "镜头厂商logo与相机logo相同")
+	}
+
+	return resolution
+}
+
+// 根据照片的exif信息获取镜头厂商logo名称,没有找到时返回UNSUPPORT_LOGO.
+func GetLensLogoNameByExif(exif exiftool.FileMetadata) string {
+	return ResolveLensLogo(exif).Logo
+}
+
+// 初始化logo匹配结果.
+func newLogoResolution(exif exiftool.FileMetadata) LogoResolution {
+	return LogoResolution{
+		Make:      strings.TrimSpace(pkg.AnyToString(exif.Fields["Make"])),
+		Model:     strings.TrimSpace(pkg.AnyToString(exif.Fields["Model"])),
+		LensMake:  strings.TrimSpace(pkg.AnyToString(exif.Fields["LensMake"])),
+		LensModel: strings.TrimSpace(pkg.AnyToString(exif.Fields["LensModel"])),
+		Logo:      UNSUPPORT_LOGO,
+		Trace:     make([]LogoTrace, 0),
+	}
+}
+
+// 使用别名匹配logo.
+func (resolution *LogoResolution) resolveByAlias(aliases map[string]string, value string) bool {
+	logo, ok := aliases[strings.ToLower(value)]
+
+	return ok && resolution.accept(LOGO_SOURCE_ALIAS, value, logo, true, "")
+}
+
+// 使用logo文件名称匹配,先匹配完全相同的名称,再匹配包含的完整单词.
+func (resolution *LogoResolution) resolveByName(source, value string) bool {
+	name := strings.ToLower(value)
+	if name == "" {
+		return false
+	}
+	names := getOriginLogoNames()
+	if slices.Contains(names, name) {
+		return resolution.accept(LOGO_SOURCE_NAME, value, name, true, "")
+	}
+	for _, logo := range names {
+		if containsLogoWord(name, logo) {
+			return resolution.accept(source, value, logo, true, "")
+		}
+	}
+
+	return false
+}
+
+// 记录没有匹配成功的原因.
+func (resolution *LogoResolution) reject(source, rule, reason string) {
+	resolution.Trace = append(resolution.Trace, LogoTrace{
+		Source: source,
+		Rule:   rule,
+		Logo:   UNSUPPORT_LOGO,
+		Reason: reason,
+	})
+}
+
+// 记录匹配过程,匹配成功并且logo文件存在时使用此logo.
+func (resolution *LogoResolution) accept(source, rule, logo string, matched bool, reason string) bool {
+	if matched {
+		writeLogosMapMtx.Lock()
+		_, ok := logos.logoMap[logo]
+		writeLogosMapMtx.Unlock()
+		if !ok {
+			matched = false
+			reason = logo + ":logo文件不存在"
+		}
+	}
+	resolution.Trace = append(resolution.Trace, LogoTrace{
+		Source:  source,
+		Rule:    rule,
+		Logo:    logo,
+		Reason:  reason,
+		Matched: matched,
+	})
+	if matched {
+		resolution.Logo = logo
+	}
+
+	return matched
+}
+
+// 获取logo文件夹中的logo名称,不包含重置尺寸之后生成的logo,名称越长越靠前.
+func getOriginLogoNames() []string {
+	writeLogosMapMtx.Lock()
+	names := make([]string, 0, len(logos.logoMap))
+	for name, logo := range logos.logoMap {
+		if logo.LogoPath != "" && !isLogoVariantName(name) {
+			names = append(names, name)
+		}
+	}
+	writeLogosMapMtx.Unlock()
+	sort.Slice(names, func(i, j int) bool {
+		if len(names[i]) != len(names[j]) {
+			return len(names[i]) > len(names[j])
+		}
+
+		return names[i] < names[j]
+	})
+
+	return names
+}
+
+// 厂商名称中是否包含完整的logo名称,防止xiaomi匹配到om这种单词中间的内容.
+func containsLogoWord(makeName, name string) bool {
+	for start := strings.Index(makeName, name); start >= 0; {
+		end := start + len(name)
+		if !isLogoWordChar(makeName, start-1) && !isLogoWordChar(makeName, end) {
+			return true
+		}
+		next := strings.Index(makeName[start+1:], name)
+		if next < 0 {
+			break
+		}
+		start += next + 1
+	}
+
+	return false
+}
+
+// 指定位置的字符是否是字母或者数字,超出范围时返回false.
+func isLogoWordChar(str string, index int) bool {
+	if index < 0 || index >= len(str) {
+		return false
+	}
+	c := str[index]
+
+	return c >= 'a' && c <= 'z' || c >= '0' && c <= '9'
+}
@@ -0,0 +1,134 @@
+package layout
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/srwiley/oksvg"
+	"github.com/srwiley/rasterx"
+
+	"WaterMark/internal"
+	"WaterMark/pkg"
+)
+
+// svg格式logo的扩展名.
+const LOGO_SVG_EXT = ".svg"
+
+// 检查logo文件是否是svg格式.
+func isSvgLogoFile(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), LOGO_SVG_EXT)
+}
+
+// 加载svg格式的logo,原始宽高使用viewBox的尺寸.
+func newSvgLogo(name, fullPath string) (*Logo, pkg.EError) {
+	data, err := os.ReadFile(fullPath)
+	if err != nil {
+		return &Logo{}, pkg.NewErrors(pkg.FILE_NOT_READ_ERROR, fullPath+":读取文件失败:"+err.Error())
+	}
+	icon, parseErr := parseSvgIcon(fullPath, data)
+	if pkg.HasError(parseErr) {
+		return &Logo{}, parseErr
+	}
+	width := int(math.Ceil(icon.ViewBox.W))
+	height := int(math.Ceil(icon.ViewBox.H))
+	logoImage := rasterizeSvgIcon(icon, width, height)
+	luminance, _ := pkg.ImageLuminance(logoImage, logoImage.Bounds())
+
+	return &Logo{
+		IsLoad:    true,
+		Width:     width,
+		Height:    height,
+		Name:      strings.ToLower(name),
+		Ext:       LOGO_SVG_EXT,
+		LogoPath:  fullPath,
+		Luminance: luminance,
+		LogoImage: logoImage,
+		svgData:   data,
+	}, pkg.NoError
+}
+
+// 解析svg内容,没有viewBox的svg无法计算宽高比例.
+func parseSvgIcon(path string, data []byte) (*oksvg.SvgIcon, pkg.EError) {
+	icon, err := oksvg.ReadIconStream(bytes.NewReader(data), oksvg.IgnoreErrorMode)
+	if err != nil {
+		return nil, pkg.NewErrors(pkg.IMAGE_LOGO_SVG_ERROR, path+":svg解析失败:"+err.Error())
+	}
+	if icon.ViewBox.W <= 0 || icon.ViewBox.H <= 0 {
+		return nil, pkg.NewErrors(pkg.IMAGE_LOGO_SVG_ERROR, path+":svg缺少viewBox或者宽高")
+	}
+
+	return icon, pkg.NoError
+}
+
+// 将svg绘制到指定宽高的透明图片中,并转换到工作色彩空间.
+func rasterizeSvgIcon(icon *oksvg.SvgIcon, width, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	icon.SetTarget(0, 0, float64(width), float64(height))
+	scanner := rasterx.NewScannerGV(width, height, img, img.Bounds())
+	icon.Draw(rasterx.NewDasher(width, height, scanner), 1)
+
+	return pkg.ConvertImageColorProfile(img, pkg.GetSRGBColorProfile(), internal.GetWorkingColorProfile())
+}
+
+// 按照指定的宽高重新绘制svg格式的logo,宽高其中一个为0时按照原始比例计算.
+func generateSvgLogoImage(logo *Logo, width, height int, fill color.Color) (image.Image, pkg.EError) {
+	// 每次重新解析,绘制时会修改svg的变换矩阵,共用同一个对象会导致并发问题
+	icon, err := parseSvgIcon(logo.LogoPath, logo.svgData)
+	if pkg.HasError(err) {
+		return nil, err
+	}
+	switch {
+	case width <= 0 && height <= 0:
+		width, height = logo.Width, logo.Height
+	case width <= 0:
+		width = logo.Width * height / logo.Height
+	case height <= 0:
+		height = logo.Height * width / logo.Width
+	}
+	img := rasterizeSvgIcon(icon, width, height)
+	if fill == nil {
+		return img, pkg.NoError
+	}
+
+	return recolorLogoImage(img, fill), pkg.NoError
+}
+
+// 使用指定颜色重新填充logo,保留原有的透明度,适用于单色的logo.
+func recolorLogoImage(img image.Image, fill color.Color) *image.RGBA {
+	bounds := img.Bounds()
+	dst := image.NewRGBA(bounds)
+	r, g, b, a := fill.RGBA()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			_, _, _, alpha := img.At(x, y).RGBA()
+			if alpha == 0 {
+				continue
+			}
+			// 按照预乘透明度的方式计算颜色
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8(r * alpha / 0xffff >> 8),
+				G: uint8(g * alpha / 0xffff >> 8),
+				B: uint8(b * alpha / 0xffff >> 8),
+				A: uint8(a * alpha / 0xffff >> 8),
+			})
+		}
+	}
+
+	return dst
+}
+
+// 获取重新着色之后的logo名称,不需要着色时返回原名称.
+func getColorLogoName(name string, fill color.Color) string {
+	if fill == nil {
+		return name
+	}
+	r, g, b, a := fill.RGBA()
+
+	return fmt.Sprintf("%s_%02x%02x%02x%02x", name, r>>8, g>>8, b>>8, a>>8)
+}
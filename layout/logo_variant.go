@@ -0,0 +1,33 @@
+package layout
+
+import (
+	"strings"
+
+	"WaterMark/pkg"
+)
+
+const (
+	// 浅色背景上使用的logo后缀,例如sony.light.png.
+	LOGO_VARIANT_LIGHT = ".light"
+
+	// 深色背景上使用的logo后缀,例如sony.dark.png.
+	LOGO_VARIANT_DARK = ".dark"
+)
+
+// 检查logo名称是否是深浅色背景对应的logo,这类logo不参与厂商名称的匹配.
+func isLogoVariantName(name string) bool {
+	return strings.HasSuffix(name, LOGO_VARIANT_LIGHT) || strings.HasSuffix(name, LOGO_VARIANT_DARK)
+}
+
+// 根据背景的深浅获取logo对应的版本名称,没有对应版本时返回false.
+func GetLogoVariantName(name string, isDarkBackground bool) (string, bool) {
+	variant := name + LOGO_VARIANT_LIGHT
+	if isDarkBackground {
+		variant = name + LOGO_VARIANT_DARK
+	}
+	if _, err := GetLogoImageByName(variant); pkg.HasError(err) {
+		return name, false
+	}
+
+	return variant, true
+}
@@ -3,11 +3,27 @@ package layout
 import (
 	"encoding/json"
 	"os"
+	"slices"
+	"strings"
 
 	"WaterMark/internal"
 	"WaterMark/pkg"
 )
 
+const (
+	// 文字对齐方式:左对齐.
+	TEXT_ALIGN_LEFT = "left"
+
+	// 文字对齐方式:居中.
+	TEXT_ALIGN_CENTER = "center"
+
+	// 文字对齐方式:右对齐.
+	TEXT_ALIGN_RIGHT = "right"
+
+	// 文字对齐方式:两端对齐,多行文字除每段的最后一行外,按照单词或者字符平均分配剩余宽度.
+	TEXT_ALIGN_JUSTIFY = "justify"
+)
+
 type (
 	FrameLayouts struct {
 		List []FrameLayout `json:"list"`
@@ -15,63 +31,211 @@ type (
 
 	// 布局.
 	FrameLayout struct {
-		TextOneFontColor      string `json:"text_one_font_color"`
-		Type                  string `json:"frame_type"`
-		Layout                string `json:"frame_layout"`
-		TextFourFontFile      string `json:"text_four_font_file"`
-		TextFourFontColor     string `json:"text_four_font_color"`
-		TextFourContent       string `json:"text_four_content"`
-		TextThreeFontFile     string `json:"text_three_font_file"`
-		BgColor               string `json:"bg_color"`
-		TextThreeFontColor    string `json:"text_three_font_color"`
-		TextThreeContent      string `json:"text_three_content"`
-		TextTwoFontFile       string `json:"text_two_font_file"`
-		Name                  string `json:"frame_name"`
-		TextTwoFontColor      string `json:"text_two_font_color"`
-		TextTwoContent        string `json:"text_two_content"`
-		TextOneContent        string `json:"text_one_content"`
-		TextOneFontFile       string `json:"text_one_font_file"`
-		SeparatorColor        string `json:"separator_color"`
-		LogoRatio             int    `json:"logo_ratio"`
-		TextRatio             int    `json:"text_ratio"`
-		LogoMarginRight       int    `json:"logo_margin_right"`
-		TextThreeMarginRight  int    `json:"text_three_margin_right"`
-		TextOneMarginLeft     int    `json:"text_one_margin_left"`
-		TextOneMarginRight    int    `json:"text_one_margin_right"`
-		TextOneMarginTop      int    `json:"text_one_margin_top"`
-		TextOneMarginBottom   int    `json:"text_one_margin_bottom"`
-		LogoMarginBottom      int    `json:"logo_margin_bottom"`
-		TextTwoFontSize       int    `json:"text_two_font_size"`
-		LogoMarginTop         int    `json:"logo_margin_top"`
-		LogoMarginLeft        int    `json:"logo_margin_left"`
-		TextTwoMarginLeft     int    `json:"text_two_margin_left"`
-		TextTwoMarginRight    int    `json:"text_two_margin_right"`
-		TextTwoMarginTop      int    `json:"text_two_margin_top"`
-		TextTwoMarginBottom   int    `json:"text_two_margin_bottom"`
-		LogoHeight            int    `json:"logo_height"`
-		TextThreeFontSize     int    `json:"text_three_font_size"`
-		LogoWidth             int    `json:"logo_width"`
-		MainMarginBottom      int    `json:"main_margin_bottom"`
-		TextThreeMarginLeft   int    `json:"text_three_margin_left"`
-		TextOneFontSize       int    `json:"text_one_font_size"`
-		TextThreeMarginTop    int    `json:"text_three_margin_top"`
-		TextThreeMarginBottom int    `json:"text_three_margin_bottom"`
-		MainMarginTop         int    `json:"main_margin_top"`
-		TextFourFontSize      int    `json:"text_four_font_size"`
-		MainMarginRight       int    `json:"main_margin_right"`
-		MainMarginLeft        int    `json:"main_margin_left"`
-		TextFourMarginLeft    int    `json:"text_four_margin_left"`
-		TextFourMarginRight   int    `json:"text_four_margin_right"`
-		TextFourMarginTop     int    `json:"text_four_margin_top"`
-		TextFourMarginBottom  int    `json:"text_four_margin_bottom"`
-		SeparatorWidth        int    `json:"separator_width"`
-		SeparatorHeight       int    `json:"separator_height"`
-		SeparatorMarginLeft   int    `json:"separator_margin_left"`
-		SeparatorMarginRight  int    `json:"separator_margin_right"`
-		SeparatorMarginTop    int    `json:"separator_margin_top"`
-		SeparatorMarginBottom int    `json:"separator_margin_bottom"`
-		BorderRadius          int    `json:"border_radius"`
-		Isblur                bool   `json:"is_blur"`
+		TextOneFontColor      string        `json:"text_one_font_color"`
+		Type                  string        `json:"frame_type"`
+		Layout                string        `json:"frame_layout"`
+		TextFourFontFile      string        `json:"text_four_font_file"`
+		TextFourFontColor     string        `json:"text_four_font_color"`
+		TextFourContent       string        `json:"text_four_content"`
+		TextThreeFontFile     string        `json:"text_three_font_file"`
+		BgColor               string        `json:"bg_color"`
+		TextThreeFontColor    string        `json:"text_three_font_color"`
+		TextThreeContent      string        `json:"text_three_content"`
+		TextTwoFontFile       string        `json:"text_two_font_file"`
+		Name                  string        `json:"frame_name"`
+		TextTwoFontColor      string        `json:"text_two_font_color"`
+		TextTwoContent        string        `json:"text_two_content"`
+		TextOneContent        string        `json:"text_one_content"`
+		TextOneFontFile       string        `json:"text_one_font_file"`
+		SeparatorColor        string        `json:"separator_color"`
+		LogoColor             string        `json:"logo_color"`
+		ShadowColor           string        `json:"shadow_color"`
+		StrokeColor           string        `json:"stroke_color"`
+		Texts                 []TextElement `json:"texts"`
+		FallbackFonts         []string      `json:"fallback_fonts"`
+		Overlays              []Overlay     `json:"overlays"`
+		TopBand               SideBand      `json:"top_band"`
+		LeftBand              SideBand      `json:"left_band"`
+		RightBand             SideBand      `json:"right_band"`
+		LensLogo              LensLogo      `json:"lens_logo"`
+		QRCode                QRCode        `json:"qr_code"`
+		LogoRatio             int           `json:"logo_ratio"`
+		TextRatio             int           `json:"text_ratio"`
+		LogoMarginRight       int           `json:"logo_margin_right"`
+		TextThreeMarginRight  int           `json:"text_three_margin_right"`
+		TextOneMarginLeft     int           `json:"text_one_margin_left"`
+		TextOneMarginRight    int           `json:"text_one_margin_right"`
+		TextOneMarginTop      int           `json:"text_one_margin_top"`
+		TextOneMarginBottom   int           `json:"text_one_margin_bottom"`
+		LogoMarginBottom      int           `json:"logo_margin_bottom"`
+		TextTwoFontSize       int           `json:"text_two_font_size"`
+		LogoMarginTop         int           `json:"logo_margin_top"`
+		LogoMarginLeft        int           `json:"logo_margin_left"`
+		TextTwoMarginLeft     int           `json:"text_two_margin_left"`
+		TextTwoMarginRight    int           `json:"text_two_margin_right"`
+		TextTwoMarginTop      int           `json:"text_two_margin_top"`
+		TextTwoMarginBottom   int           `json:"text_two_margin_bottom"`
+		LogoHeight            int           `json:"logo_height"`
+		TextThreeFontSize     int           `json:"text_three_font_size"`
+		LogoWidth             int           `json:"logo_width"`
+		MainMarginBottom      int           `json:"main_margin_bottom"`
+		TextThreeMarginLeft   int           `json:"text_three_margin_left"`
+		TextOneFontSize       int           `json:"text_one_font_size"`
+		TextThreeMarginTop    int           `json:"text_three_margin_top"`
+		TextThreeMarginBottom int           `json:"text_three_margin_bottom"`
+		MainMarginTop         int           `json:"main_margin_top"`
+		TextFourFontSize      int           `json:"text_four_font_size"`
+		MainMarginRight       int           `json:"main_margin_right"`
+		MainMarginLeft        int           `json:"main_margin_left"`
+		TextFourMarginLeft    int           `json:"text_four_margin_left"`
+		TextFourMarginRight   int           `json:"text_four_margin_right"`
+		TextFourMarginTop     int           `json:"text_four_margin_top"`
+		TextFourMarginBottom  int           `json:"text_four_margin_bottom"`
+		SeparatorWidth        int           `json:"separator_width"`
+		SeparatorHeight       int           `json:"separator_height"`
+		SeparatorMarginLeft   int           `json:"separator_margin_left"`
+		SeparatorMarginRight  int           `json:"separator_margin_right"`
+		SeparatorMarginTop    int           `json:"separator_margin_top"`
+		SeparatorMarginBottom int           `json:"separator_margin_bottom"`
+		BorderRadius          int           `json:"border_radius"`
+		ShadowOpacity         int           `json:"shadow_opacity"`
+		ShadowBlur            int           `json:"shadow_blur"`
+		ShadowOffsetX         int           `json:"shadow_offset_x"`
+		ShadowOffsetY         int           `json:"shadow_offset_y"`
+		StrokeWidth           int           `json:"stroke_width"`
+		Isblur                bool          `json:"is_blur"`
+		AutoContrast          bool          `json:"auto_contrast"`
+	}
+
+	// 文字元素.
+	TextElement struct {
+		// 文字内容,使用#包裹exif字段.
+		Content string `json:"content"`
+		// 字体文件.
+		FontFile string `json:"font_file"`
+		// 字体颜色.
+		FontColor string `json:"font_color"`
+		// 对齐方式:left,center,right,justify,默认left,多行文字在文字区域内按照对齐方式排列.
+		Align string `json:"align"`
+		// 描边颜色,为空时使用黑色.
+		StrokeColor string `json:"stroke_color"`
+		// 阴影颜色,为空时不展示阴影.
+		ShadowColor string `json:"shadow_color"`
+		// 备用字体,字体文件中没有的字符依次使用备用字体绘制,为空时使用模板的fallback_fonts.
+		FallbackFonts []string `json:"fallback_fonts"`
+		// 字体大小.
+		FontSize     int `json:"font_size"`
+		MarginLeft   int `json:"margin_left"`
+		MarginRight  int `json:"margin_right"`
+		MarginTop    int `json:"margin_top"`
+		MarginBottom int `json:"margin_bottom"`
+		// 描边宽度,阴影偏移,阴影模糊与字间距都是字体大小的百分比,字间距可以为负数.
+		StrokeWidth   int `json:"stroke_width"`
+		ShadowOffsetX int `json:"shadow_offset_x"`
+		ShadowOffsetY int `json:"shadow_offset_y"`
+		ShadowBlur    int `json:"shadow_blur"`
+		LetterSpacing int `json:"letter_spacing"`
+		// 不透明度,范围1-100,为0时不透明.
+		Opacity int `json:"opacity"`
+		// 文字区域的最大宽度,超出时自动换行,为0时只按照内容中的换行符换行.
+		MaxWidth int `json:"max_width"`
+		// 行高,字体大小的百分比,为0时使用默认行高.
+		LineHeight int `json:"line_height"`
+	}
+
+	// 镜头厂商logo,按照exif中的LensMake与LensModel查找,展示在相机logo的右边.
+	LensLogo struct {
+		// logo高度占相机logo高度的百分比,为0时与相机logo等高.
+		Ratio int `json:"ratio"`
+		// 与相机logo之间的距离,为0时使用镜头logo高度的一半.
+		Spacing int `json:"spacing"`
+		// 宽高与边距,自动布局时自动计算,固定布局时需要手动指定.
+		Width        int `json:"width"`
+		Height       int `json:"height"`
+		MarginLeft   int `json:"margin_left"`
+		MarginRight  int `json:"margin_right"`
+		MarginTop    int `json:"margin_top"`
+		MarginBottom int `json:"margin_bottom"`
+		// 是否展示镜头厂商logo,没有找到logo或者与相机logo相同时不展示.
+		Show bool `json:"show"`
+	}
+
+	// 边框中的二维码,内容为空时不展示.
+	QRCode struct {
+		// 二维码内容,与其它文字一样使用#包裹exif字段,例如作品集链接或者#GPSGeoURI#.
+		Content string `json:"content"`
+		// 容错级别:L,M,Q,H,默认M.
+		Level string `json:"level"`
+		// 二维码颜色,为空时使用第一行文字的颜色.
+		Color string `json:"color"`
+		// 背景颜色,为空时使用边框的背景颜色.
+		BgColor string `json:"bg_color"`
+		// 位置:logo展示在logo旁边,text展示在文字旁边,默认text,只在自动布局中生效.
+		Position string `json:"position"`
+		// 高度占相机logo高度的百分比,为0时与相机logo等高.
+		Ratio int `json:"ratio"`
+		// 与相邻元素之间的距离,为0时使用二维码尺寸的一半.
+		Spacing int `json:"spacing"`
+		// 二维码四周的空白,单位为二维码的模块,为0时不留空白.
+		QuietZone int `json:"quiet_zone"`
+		// 尺寸与边距,自动布局时自动计算,固定布局时需要手动指定.
+		Size         int `json:"size"`
+		MarginLeft   int `json:"margin_left"`
+		MarginRight  int `json:"margin_right"`
+		MarginTop    int `json:"margin_top"`
+		MarginBottom int `json:"margin_bottom"`
+	}
+
+	// 照片上,左,右边框中展示的文字与logo,左右边框中的内容竖排展示.
+	SideBand struct {
+		// 文字内容,与其它文字一样使用#包裹exif字段.
+		Content string `json:"content"`
+		// 字体文件,为空时使用第一行文字的字体.
+		FontFile string `json:"font_file"`
+		// 字体颜色,为空时使用第一行文字的颜色.
+		FontColor string `json:"font_color"`
+		// 对齐方式:start,center,end,按照文字的阅读方向计算,默认居中.
+		Align string `json:"align"`
+		// 备用字体,为空时使用模板的fallback_fonts,没有指定字体文件时使用第一行文字的备用字体.
+		FallbackFonts []string `json:"fallback_fonts"`
+		// 字体大小,为0时按照text_ratio自动计算.
+		FontSize int `json:"font_size"`
+		// 字体大小占边框宽度的百分比.
+		TextRatio int `json:"text_ratio"`
+		// logo高度占边框宽度的百分比,为0时不展示logo.
+		LogoRatio int `json:"logo_ratio"`
+	}
+
+	// 绘制在照片上的水印,可以单独使用也可以与任意边框一起使用,同时配置文字与图片时图片在文字的左边.
+	Overlay struct {
+		// 文字内容,与其它文字一样使用#包裹exif字段.
+		Content string `json:"content"`
+		// 字体文件,为空时使用第一行文字的字体.
+		FontFile string `json:"font_file"`
+		// 字体颜色,为空时使用白色.
+		FontColor string `json:"font_color"`
+		// 图片,logos文件夹中的logo名称或者png图片的完整路径.
+		Image string `json:"image"`
+		// 位置:top_left,top,top_right,left,center,right,bottom_left,bottom,bottom_right,absolute,默认bottom_right.
+		Anchor string `json:"anchor"`
+		// 重复方式:none,tile平铺,diagonal错位平铺,默认none.
+		Repeat string `json:"repeat"`
+		// 备用字体,为空时使用模板的fallback_fonts.
+		FallbackFonts []string `json:"fallback_fonts"`
+		// 文字大小与图片高度,照片短边的百分比.
+		TextSize  int `json:"text_size"`
+		ImageSize int `json:"image_size"`
+		// 与照片边缘的距离,照片短边的百分比.
+		Margin int `json:"margin"`
+		// anchor为absolute时水印中心的位置,照片宽高的百分比.
+		X int `json:"x"`
+		Y int `json:"y"`
+		// 重复时水印之间的距离,照片短边的百分比,为0时使用默认距离.
+		Spacing int `json:"spacing"`
+		// 不透明度,范围1-100,为0时不透明.
+		Opacity int `json:"opacity"`
+		// 逆时针旋转的角度.
+		Rotation int `json:"rotation"`
 	}
 )
 
@@ -126,9 +290,162 @@ func loadandInitLayout() pkg.EError {
 	return pkg.NoError
 }
 
-// 重新加载模板.
+// 重新加载模板,同时重新加载相机与镜头名称映射文件,地名数据库在下次使用时重新加载.
 func ReloadandInitLayout() pkg.EError {
 	frameLayouts = &FrameLayouts{}
+	if err := loadandInitLayout(); pkg.HasError(err) {
+		return err
+	}
+	clearPlaces()
+
+	return ReloadDisplayNames()
+}
 
-	return loadandInitLayout()
+// 构造生成水印的布局参数,layoutStr为JSON字符串,必须包含frame_name字段.
+func BuildFrameLayout(layoutStr string) (FrameLayout, pkg.EError) {
+	var frameLayout FrameLayout
+	// json序列化为布局
+	jsonErr := json.Unmarshal([]byte(layoutStr), &frameLayout)
+	if jsonErr != nil {
+		return frameLayout, pkg.NewErrors(pkg.REQUEST_PARAM_ERROR, layoutStr+":布局信息格式错误,json解析失败")
+	}
+	// 查找布局
+	templateLayout, findErr := FindLayoutByName(frameLayout.Name)
+	if pkg.HasError(findErr) {
+		return frameLayout, findErr
+	}
+	// 列表字段与模板共用底层数组,合并前需要复制一份,防止修改模板
+	templateLayout = templateLayout.clone()
+	// 将外部传递的参数合并到布局中
+	jsonErr = json.NewDecoder(strings.NewReader(layoutStr)).Decode(&templateLayout)
+	if jsonErr != nil {
+		return frameLayout, pkg.NewErrors(pkg.REQUEST_PARAM_ERROR, layoutStr+":布局信息格式错误,json解析失败")
+	}
+	// 文字表达式在构建布局时解析,表达式错误直接返回
+	if err := CheckLayoutTextTemplate(&templateLayout); pkg.HasError(err) {
+		return templateLayout, err
+	}
+
+	return templateLayout, checkLayoutTemplateFont(templateLayout)
+}
+
+// 复制布局,包括其中全部的列表字段,复制之后的布局与原布局不再共用底层数组.
+func (fl *FrameLayout) clone() FrameLayout {
+	cloned := *fl
+	cloned.FallbackFonts = slices.Clone(fl.FallbackFonts)
+	cloned.Texts = slices.Clone(fl.Texts)
+	for i := range cloned.Texts {
+		cloned.Texts[i].FallbackFonts = slices.Clone(fl.Texts[i].FallbackFonts)
+	}
+	cloned.TopBand.FallbackFonts = slices.Clone(fl.TopBand.FallbackFonts)
+	cloned.LeftBand.FallbackFonts = slices.Clone(fl.LeftBand.FallbackFonts)
+	cloned.RightBand.FallbackFonts = slices.Clone(fl.RightBand.FallbackFonts)
+	cloned.Overlays = slices.Clone(fl.Overlays)
+	for i := range cloned.Overlays {
+		cloned.Overlays[i].FallbackFonts = slices.Clone(fl.Overlays[i].FallbackFonts)
+	}
+
+	return cloned
+}
+
+// 检查模板中指定的字体文件是否存在.
+//
+//nolint:gocritic
+func checkLayoutTemplateFont(templateLayout FrameLayout) pkg.EError {
+	fontFiles := []string{
+		templateLayout.TopBand.FontFile,
+		templateLayout.LeftBand.FontFile,
+		templateLayout.RightBand.FontFile,
+	}
+	fontFiles = append(fontFiles, templateLayout.FallbackFonts...)
+	fontFiles = append(fontFiles, templateLayout.TopBand.FallbackFonts...)
+	fontFiles = append(fontFiles, templateLayout.LeftBand.FallbackFonts...)
+	fontFiles = append(fontFiles, templateLayout.RightBand.FallbackFonts...)
+	for _, text := range templateLayout.GetTexts() {
+		fontFiles = append(fontFiles, text.FontFile)
+		fontFiles = append(fontFiles, text.FallbackFonts...)
+	}
+	for _, overlay := range templateLayout.Overlays {
+		fontFiles = append(fontFiles, overlay.FontFile)
+		fontFiles = append(fontFiles, overlay.FallbackFonts...)
+	}
+
+	// 读取字体库下面的全部文件,全部提前初始化
+	fontDir := internal.GetFontFilePath("")
+	list, err := pkg.GetDirFiles(fontDir)
+	if pkg.HasError(err) {
+		return err
+	}
+	for _, font := range fontFiles {
+		if font == "" {
+			continue
+		}
+		if !pkg.In(font, list) {
+			return pkg.NewErrors(pkg.FILE_NOT_EXIST_ERROR, font+":字体文件不存在")
+		}
+	}
+
+	return pkg.NoError
+}
+
+// 获取布局中的文字列表,没有配置texts时按照旧版本的四个文字字段生成,返回的列表可以直接修改
+// 没有配置备用字体的文字使用模板的备用字体.
+func (fl *FrameLayout) GetTexts() []TextElement {
+	if len(fl.Texts) > 0 {
+		texts := slices.Clone(fl.Texts)
+		for i := range texts {
+			if len(texts[i].FallbackFonts) == 0 {
+				texts[i].FallbackFonts = fl.FallbackFonts
+			}
+		}
+
+		return texts
+	}
+
+	return []TextElement{
+		{
+			Content:       fl.TextOneContent,
+			FontFile:      fl.TextOneFontFile,
+			FontColor:     fl.TextOneFontColor,
+			FallbackFonts: fl.FallbackFonts,
+			FontSize:      fl.TextOneFontSize,
+			MarginLeft:    fl.TextOneMarginLeft,
+			MarginRight:   fl.TextOneMarginRight,
+			MarginTop:     fl.TextOneMarginTop,
+			MarginBottom:  fl.TextOneMarginBottom,
+		},
+		{
+			Content:       fl.TextTwoContent,
+			FontFile:      fl.TextTwoFontFile,
+			FontColor:     fl.TextTwoFontColor,
+			FallbackFonts: fl.FallbackFonts,
+			FontSize:      fl.TextTwoFontSize,
+			MarginLeft:    fl.TextTwoMarginLeft,
+			MarginRight:   fl.TextTwoMarginRight,
+			MarginTop:     fl.TextTwoMarginTop,
+			MarginBottom:  fl.TextTwoMarginBottom,
+		},
+		{
+			Content:       fl.TextThreeContent,
+			FontFile:      fl.TextThreeFontFile,
+			FontColor:     fl.TextThreeFontColor,
+			FallbackFonts: fl.FallbackFonts,
+			FontSize:      fl.TextThreeFontSize,
+			MarginLeft:    fl.TextThreeMarginLeft,
+			MarginRight:   fl.TextThreeMarginRight,
+			MarginTop:     fl.TextThreeMarginTop,
+			MarginBottom:  fl.TextThreeMarginBottom,
+		},
+		{
+			Content:       fl.TextFourContent,
+			FontFile:      fl.TextFourFontFile,
+			FontColor:     fl.TextFourFontColor,
+			FallbackFonts: fl.FallbackFonts,
+			FontSize:      fl.TextFourFontSize,
+			MarginLeft:    fl.TextFourMarginLeft,
+			MarginRight:   fl.TextFourMarginRight,
+			MarginTop:     fl.TextFourMarginTop,
+			MarginBottom:  fl.TextFourMarginBottom,
+		},
+	}
 }
@@ -0,0 +1,234 @@
+package layout
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/yijianlingcheng/go-exiftool"
+
+	"WaterMark/pkg"
+)
+
+// 文字表达式
+// 文字内容中使用{{ }}包裹的部分作为表达式计算,其余部分原样展示,例如:
+//
+//	f/{{FNumber|%.1f}}
+//	{{LensModel ?? Lens ?? 'Unknown lens'}}
+//	{{ISO >= 3200 ? 'High ISO' : ''}}
+//	{{Model|upper|truncate(12)}}
+//	{{DateTimeOriginal|date('2006.01.02')}}
+//
+// 不包含{{的文字继续使用#字段#的方式替换.
+type (
+	// 解析之后的文字表达式.
+	TextTemplate struct {
+		parts []textTemplatePart
+	}
+
+	// 文字表达式中的一段,expr为空时表示原样展示的文字.
+	textTemplatePart struct {
+		expr    textExpr
+		literal string
+	}
+
+	// 表达式.
+	textExpr interface {
+		eval(fields map[string]any) string
+	}
+
+	// 字段.
+	fieldExpr struct {
+		name string
+	}
+
+	// 字符串或者数字.
+	literalExpr struct {
+		value string
+	}
+
+	// 默认值,返回第一个不为空的值.
+	coalesceExpr struct {
+		list []textExpr
+	}
+
+	// 条件.
+	condExpr struct {
+		cond textExpr
+		yes  textExpr
+		no   textExpr
+	}
+
+	// 比较.
+	compareExpr struct {
+		left  textExpr
+		right textExpr
+		op    string
+	}
+
+	// 过滤器.
+	pipeExpr struct {
+		value textExpr
+		name  string
+		args  []string
+	}
+)
+
+// 解析之后的文字表达式缓存,同一个文字内容只解析一次.
+var textTemplateCache sync.Map
+
+// 文字内容是否使用表达式.
+func IsTextTemplate(str string) bool {
+	return strings.Contains(str, "{{")
+}
+
+// 解析文字表达式,解析结果会被缓存.
+func CompileTextTemplate(src string) (*TextTemplate, pkg.EError) {
+	if cache, ok := textTemplateCache.Load(src); ok {
+		if tpl, tplOk := cache.(*TextTemplate); tplOk {
+			return tpl, pkg.NoError
+		}
+	}
+	tpl := &TextTemplate{parts: make([]textTemplatePart, 0)}
+	rest := src
+	for rest != "" {
+		start := strings.Index(rest, "{{")
+		if start < 0 {
+			tpl.parts = append(tpl.parts, textTemplatePart{literal: rest})
+
+			break
+		}
+		end := strings.Index(rest[start:], "}}")
+		if end < 0 {
+			return nil, pkg.NewErrors(pkg.LAYOUT_TEXT_TEMPLATE_ERROR, src+":文字表达式错误,{{没有对应的}}")
+		}
+		expr, err := parseTextExpr(rest[start+2 : start+end])
+		if err != nil {
+			return nil, pkg.NewErrors(pkg.LAYOUT_TEXT_TEMPLATE_ERROR, src+":文字表达式错误:"+err.Error())
+		}
+		tpl.parts = append(tpl.parts, textTemplatePart{literal: rest[:start]}, textTemplatePart{expr: expr})
+		rest = rest[start+end+2:]
+	}
+	textTemplateCache.Store(src, tpl)
+
+	return tpl, pkg.NoError
+}
+
+// 使用照片的exif信息计算文字表达式,表达式错误时原样返回.
+func ExecuteTextTemplate(src string, exif exiftool.FileMetadata) string {
+	tpl, err := CompileTextTemplate(src)
+	if pkg.HasError(err) {
+		return src
+	}
+
+	return tpl.Execute(exif.Fields)
+}
+
+// 检查布局中全部文字的表达式是否正确.
+func CheckLayoutTextTemplate(fl *FrameLayout) pkg.EError {
+	contents := []string{fl.TopBand.Content, fl.LeftBand.Content, fl.RightBand.Content, fl.QRCode.Content}
+	for _, text := range fl.GetTexts() {
+		contents = append(contents, text.Content)
+	}
+	for i := range fl.Overlays {
+		contents = append(contents, fl.Overlays[i].Content)
+	}
+	for _, content := range contents {
+		if !IsTextTemplate(content) {
+			continue
+		}
+		if _, err := CompileTextTemplate(content); pkg.HasError(err) {
+			return err
+		}
+	}
+
+	return pkg.NoError
+}
+
+// 计算文字表达式.
+func (tpl *TextTemplate) Execute(fields map[string]any) string {
+	var sb strings.Builder
+	for _, part := range tpl.parts {
+		if part.expr == nil {
+			sb.WriteString(part.literal)
+
+			continue
+		}
+		sb.WriteString(part.expr.eval(fields))
+	}
+
+	return sb.String()
+}
+
+func (e *fieldExpr) eval(fields map[string]any) string {
+	return strings.TrimSpace(pkg.AnyToString(fields[e.name]))
+}
+
+func (e *literalExpr) eval(_ map[string]any) string {
+	return e.value
+}
+
+func (e *coalesceExpr) eval(fields map[string]any) string {
+	for _, expr := range e.list {
+		if v := expr.eval(fields); v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
+
+func (e *condExpr) eval(fields map[string]any) string {
+	if isTextTruthy(e.cond.eval(fields)) {
+		return e.yes.eval(fields)
+	}
+
+	return e.no.eval(fields)
+}
+
+func (e *compareExpr) eval(fields map[string]any) string {
+	if compareTextValue(e.left.eval(fields), e.right.eval(fields), e.op) {
+		return "true"
+	}
+
+	return ""
+}
+
+func (e *pipeExpr) eval(fields map[string]any) string {
+	return textFilters[e.name].apply(e.value.eval(fields), e.args)
+}
+
+// 条件是否成立,空字符串,0与false为不成立.
+func isTextTruthy(v string) bool {
+	return v != "" && v != "0" && !strings.EqualFold(v, "false")
+}
+
+// 比较两个值,两者都是数字时按照数字比较,否则按照字符串比较.
+func compareTextValue(left, right, op string) bool {
+	l, lok := parseTextNumber(left)
+	r, rok := parseTextNumber(right)
+	cmp := strings.Compare(left, right)
+	if lok && rok {
+		switch {
+		case l < r:
+			cmp = -1
+		case l > r:
+			cmp = 1
+		default:
+			cmp = 0
+		}
+	}
+	switch op {
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	default:
+		return cmp <= 0
+	}
+}
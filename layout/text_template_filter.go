@@ -0,0 +1,145 @@
+package layout
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// exif中拍摄时间的格式.
+const EXIF_DATE_TIME_LAYOUT = "2006:01:02 15:04:05"
+
+// 文字表达式过滤器.
+type textFilter struct {
+	apply func(value string, args []string) string
+	argc  int
+}
+
+// 文字表达式支持的过滤器.
+var textFilters = map[string]textFilter{
+	// 转换为大写
+	"upper": {argc: 0, apply: func(value string, _ []string) string {
+		return strings.ToUpper(value)
+	}},
+	// 转换为小写
+	"lower": {argc: 0, apply: func(value string, _ []string) string {
+		return strings.ToLower(value)
+	}},
+	// 去除首尾空格
+	"trim": {argc: 0, apply: func(value string, _ []string) string {
+		return strings.TrimSpace(value)
+	}},
+	// 截取前n个字符
+	"truncate": {argc: 1, apply: filterTruncate},
+	// 值为空时使用默认值
+	"default": {argc: 1, apply: func(value string, args []string) string {
+		if value == "" {
+			return args[0]
+		}
+
+		return value
+	}},
+	// 按照printf格式化,例如%.1f,%d
+	"format": {argc: 1, apply: filterFormat},
+	// 按照go的时间格式格式化拍摄时间,例如2006.01.02
+	"date": {argc: 1, apply: filterDate},
+	// 格式化经纬度
+	"gps": {argc: 0, apply: func(value string, _ []string) string {
+		if value == "" {
+			return value
+		}
+
+		return GpsFormat(value)
+	}},
+	// 字符串替换
+	"replace": {argc: 2, apply: func(value string, args []string) string {
+		return strings.ReplaceAll(value, args[0], args[1])
+	}},
+}
+
+// 检查过滤器是否存在以及参数数量是否正确.
+func checkTextFilter(name string, argc int) error {
+	filter, ok := textFilters[name]
+	if !ok {
+		return fmt.Errorf("不支持的过滤器%q", name)
+	}
+	if filter.argc != argc {
+		return fmt.Errorf("过滤器%q需要%d个参数", name, filter.argc)
+	}
+
+	return nil
+}
+
+// 截取前n个字符,按照字符而不是字节计算.
+func filterTruncate(value string, args []string) string {
+	n, err := strconv.Atoi(args[0])
+	runes := []rune(value)
+	if err != nil || n < 0 || len(runes) <= n {
+		return value
+	}
+
+	return string(runes[:n])
+}
+
+// 按照printf格式化,值不是数字却使用数字格式时原样返回.
+func filterFormat(value string, args []string) string {
+	format := args[0]
+	if value == "" || format == "" {
+		return value
+	}
+	num, ok := parseTextNumber(value)
+	switch format[len(format)-1] {
+	case 'd', 'x', 'X', 'o', 'b':
+		if ok {
+			return fmt.Sprintf(format, int64(math.Round(num)))
+		}
+	case 'f', 'F', 'e', 'E', 'g', 'G':
+		if ok {
+			return fmt.Sprintf(format, num)
+		}
+	default:
+		return fmt.Sprintf(format, value)
+	}
+
+	return value
+}
+
+// 按照go的时间格式格式化exif中的时间,无法解析时原样返回.
+func filterDate(value string, args []string) string {
+	if len(value) < len(EXIF_DATE_TIME_LAYOUT) {
+		return value
+	}
+	t, err := time.Parse(EXIF_DATE_TIME_LAYOUT, value[:len(EXIF_DATE_TIME_LAYOUT)])
+	if err != nil {
+		return value
+	}
+
+	return t.Format(args[0])
+}
+
+// 解析数字,支持1/200这种分数以及50.0 mm这种带单位的数字.
+func parseTextNumber(value string) (float64, bool) {
+	value = strings.TrimSpace(value)
+	if numerator, denominator, ok := strings.Cut(value, "/"); ok {
+		n, nok := parseTextNumber(numerator)
+		d, dok := parseTextNumber(denominator)
+		if !nok || !dok || d == 0 {
+			return 0, false
+		}
+
+		return n / d, true
+	}
+	end := 0
+	for end < len(value) && (value[end] >= '0' && value[end] <= '9' || value[end] == '.' ||
+		(end == 0 && (value[end] == '-' || value[end] == '+'))) {
+		end++
+	}
+	num, err := strconv.ParseFloat(value[:end], 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return num, true
+}
@@ -0,0 +1,342 @@
+package layout
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+const (
+	// 词法单元:字段名称或者过滤器名称.
+	tokenIdent = iota + 1
+
+	// 词法单元:字符串.
+	tokenString
+
+	// 词法单元:数字.
+	tokenNumber
+
+	// 词法单元:格式化字符串,例如%.1f.
+	tokenFormat
+
+	// 词法单元:运算符与标点.
+	tokenOperator
+)
+
+type (
+	// 词法单元.
+	textToken struct {
+		value string
+		kind  int
+		pos   int
+		// 字符串结束的位置,转义字符会导致value与原始内容的长度不一致.
+		end int
+	}
+
+	// 表达式解析器.
+	textTemplateParser struct {
+		tokens []textToken
+		index  int
+	}
+)
+
+// 运算符列表,两个字符的运算符需要放在前面优先匹配.
+var textOperators = []string{"??", "==", "!=", ">=", "<=", "?", ":", "|", "(", ")", ",", ">", "<"}
+
+// 解析{{ }}中的表达式.
+func parseTextExpr(src string) (textExpr, error) {
+	tokens, err := tokenizeTextExpr(src)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, errors.New("表达式为空")
+	}
+	parser := &textTemplateParser{tokens: tokens}
+	expr, err := parser.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if token, ok := parser.peek(); ok {
+		return nil, fmt.Errorf("位置%d:多余的内容%q", token.pos, token.value)
+	}
+
+	return expr, nil
+}
+
+// 词法分析.
+func tokenizeTextExpr(src string) ([]textToken, error) {
+	tokens := make([]textToken, 0)
+	runes := []rune(src)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		var token textToken
+		var err error
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+			continue
+		case r == '\'' || r == '"':
+			token, err = scanTextString(runes, i)
+		case r == '%':
+			token = scanTextWhile(runes, i, tokenFormat, func(c rune) bool {
+				return !unicode.IsSpace(c) && !strings.ContainsRune("|)?:", c)
+			})
+		case unicode.IsDigit(r) || (r == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			token = scanTextWhile(runes, i+1, tokenNumber, func(c rune) bool {
+				return unicode.IsDigit(c) || c == '.'
+			})
+			token.value = string(r) + token.value
+			token.pos = i
+		case unicode.IsLetter(r) || r == '_':
+			token = scanTextWhile(runes, i, tokenIdent, func(c rune) bool {
+				return unicode.IsLetter(c) || unicode.IsDigit(c) || c == '_'
+			})
+		default:
+			token, err = scanTextOperator(runes, i)
+		}
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+		i = token.pos + token.width()
+	}
+
+	return tokens, nil
+}
+
+// 读取满足条件的连续字符.
+func scanTextWhile(runes []rune, start, kind int, accept func(c rune) bool) textToken {
+	end := start
+	for end < len(runes) && accept(runes[end]) {
+		end++
+	}
+
+	return textToken{kind: kind, value: string(runes[start:end]), pos: start}
+}
+
+// 读取字符串,支持单引号与双引号,使用\转义.
+func scanTextString(runes []rune, start int) (textToken, error) {
+	quote := runes[start]
+	var sb strings.Builder
+	for i := start + 1; i < len(runes); i++ {
+		switch runes[i] {
+		case '\\':
+			if i+1 < len(runes) {
+				i++
+				sb.WriteRune(runes[i])
+			}
+		case quote:
+			return textToken{kind: tokenString, value: sb.String(), pos: start, end: i + 1}, nil
+		default:
+			sb.WriteRune(runes[i])
+		}
+	}
+
+	return textToken{}, fmt.Errorf("位置%d:字符串没有结束", start)
+}
+
+// 读取运算符.
+func scanTextOperator(runes []rune, start int) (textToken, error) {
+	rest := string(runes[start:])
+	for _, op := range textOperators {
+		if strings.HasPrefix(rest, op) {
+			return textToken{kind: tokenOperator, value: op, pos: start}, nil
+		}
+	}
+
+	return textToken{}, fmt.Errorf("位置%d:无法识别的字符%q", start, runes[start])
+}
+
+// 词法单元在原始表达式中占用的字符数.
+func (t textToken) width() int {
+	if t.end > 0 {
+		return t.end - t.pos
+	}
+
+	return len([]rune(t.value))
+}
+
+// 查看下一个词法单元.
+func (p *textTemplateParser) peek() (textToken, bool) {
+	if p.index >= len(p.tokens) {
+		return textToken{}, false
+	}
+
+	return p.tokens[p.index], true
+}
+
+// 下一个词法单元是否是指定的运算符,是则跳过.
+func (p *textTemplateParser) accept(op string) bool {
+	token, ok := p.peek()
+	if ok && token.kind == tokenOperator && token.value == op {
+		p.index++
+
+		return true
+	}
+
+	return false
+}
+
+// 读取下一个词法单元.
+func (p *textTemplateParser) next() (textToken, error) {
+	token, ok := p.peek()
+	if !ok {
+		return token, errors.New("表达式不完整")
+	}
+	p.index++
+
+	return token, nil
+}
+
+// 条件表达式: cond ? a : b.
+func (p *textTemplateParser) parseExpr() (textExpr, error) {
+	cond, err := p.parseCoalesce()
+	if err != nil || !p.accept("?") {
+		return cond, err
+	}
+	yes, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.accept(":") {
+		return nil, errors.New("条件表达式缺少:")
+	}
+	no, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	return &condExpr{cond: cond, yes: yes, no: no}, nil
+}
+
+// 默认值表达式: a ?? b ?? 'c'.
+func (p *textTemplateParser) parseCoalesce() (textExpr, error) {
+	first, err := p.parseCompare()
+	if err != nil {
+		return nil, err
+	}
+	list := []textExpr{first}
+	for p.accept("??") {
+		expr, err := p.parseCompare()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, expr)
+	}
+	if len(list) == 1 {
+		return first, nil
+	}
+
+	return &coalesceExpr{list: list}, nil
+}
+
+// 比较表达式: a >= b.
+func (p *textTemplateParser) parseCompare() (textExpr, error) {
+	left, err := p.parsePipeline()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"==", "!=", ">=", "<=", ">", "<"} {
+		if !p.accept(op) {
+			continue
+		}
+		right, err := p.parsePipeline()
+		if err != nil {
+			return nil, err
+		}
+
+		return &compareExpr{op: op, left: left, right: right}, nil
+	}
+
+	return left, nil
+}
+
+// 管道表达式: value | filter | filter(args).
+func (p *textTemplateParser) parsePipeline() (textExpr, error) {
+	expr, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("|") {
+		token, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		// 格式化字符串是format过滤器的简写
+		if token.kind == tokenFormat {
+			expr = &pipeExpr{value: expr, name: "format", args: []string{token.value}}
+
+			continue
+		}
+		if token.kind != tokenIdent {
+			return nil, fmt.Errorf("位置%d:%q不是过滤器名称", token.pos, token.value)
+		}
+		args, err := p.parseFilterArgs()
+		if err != nil {
+			return nil, err
+		}
+		if err := checkTextFilter(token.value, len(args)); err != nil {
+			return nil, fmt.Errorf("位置%d:%w", token.pos, err)
+		}
+		expr = &pipeExpr{value: expr, name: token.value, args: args}
+	}
+
+	return expr, nil
+}
+
+// 过滤器参数,只支持字符串与数字.
+func (p *textTemplateParser) parseFilterArgs() ([]string, error) {
+	args := make([]string, 0)
+	if !p.accept("(") {
+		return args, nil
+	}
+	if p.accept(")") {
+		return args, nil
+	}
+	for {
+		token, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		if token.kind != tokenString && token.kind != tokenNumber && token.kind != tokenFormat {
+			return nil, fmt.Errorf("位置%d:过滤器参数只能是字符串或者数字", token.pos)
+		}
+		args = append(args, token.value)
+		if p.accept(")") {
+			return args, nil
+		}
+		if !p.accept(",") {
+			return nil, fmt.Errorf("位置%d:过滤器参数缺少)", token.pos)
+		}
+	}
+}
+
+// 基础表达式:字段,字符串,数字,括号.
+func (p *textTemplateParser) parsePrimary() (textExpr, error) {
+	token, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	switch token.kind {
+	case tokenIdent:
+		return &fieldExpr{name: token.value}, nil
+	case tokenString, tokenNumber:
+		return &literalExpr{value: token.value}, nil
+	}
+	if token.kind == tokenOperator && token.value == "(" {
+		expr, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, fmt.Errorf("位置%d:括号没有闭合", token.pos)
+		}
+
+		return expr, nil
+	}
+
+	return nil, fmt.Errorf("位置%d:不能以%q开始", token.pos, token.value)
+}
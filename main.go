@@ -2,9 +2,11 @@ package main
 
 import (
 	"embed"
+	"os"
 	"runtime/debug"
 
 	"WaterMark/api"
+	"WaterMark/cli"
 	"WaterMark/internal"
 	"WaterMark/ui"
 )
@@ -33,6 +35,10 @@ func main() {
 	debug.SetMemoryLimit(2 * 1024 * 1024 * 1024)
 	// 设置运行模式
 	internal.SetAppMode(internal.APP_RELEASE)
+	// 命令行模式,不启动UI与api服务
+	if cli.IsCommand(os.Args) {
+		os.Exit(cli.Run(os.Args[1:]))
+	}
 	// 初始化配置与资源
 	internal.InitAppConfigsAndRes()
 	// 替换版本说明文件中的APP版本号
@@ -25,6 +25,15 @@ const (
 	// 从缓存中获取的exif 缓存类型断言失败.
 	EXIFTOOL_IMAGE_EXIF_CACHE_ERROR = 2000004
 
+	// ImageMagick工具不存在.
+	IMAGEMAGICK_NOTEXIST_ERROR = 2000005
+
+	// 工具资源释放失败.
+	TOOL_RESTORE_ERROR = 2000006
+
+	// exiftool工具写入元数据失败.
+	EXIFTOOL_WRITE_METADATA_ERROR = 2000007
+
 	// csv文件创建失败.
 	CSV_CREATE_ERROR = 3000001
 
@@ -61,12 +70,39 @@ const (
 	// jpeg图片保存失败.
 	IMAGE_JPEG_SAVE_ERROR = 4000009
 
+	// ICC色彩配置文件解析失败.
+	IMAGE_ICC_PROFILE_ERROR = 4000010
+
+	// RAW文件内嵌预览图片提取失败.
+	IMAGE_RAW_PREVIEW_ERROR = 4000011
+
+	// logo匹配规则文件错误.
+	IMAGE_LOGO_RULE_ERROR = 4000012
+
+	// svg格式的logo解析失败.
+	IMAGE_LOGO_SVG_ERROR = 4000013
+
+	// 照片中没有找到隐形水印,或者水印的所有者不匹配.
+	IMAGE_INVISIBLE_WATERMARK_ERROR = 4000014
+
+	// 二维码生成失败,通常是内容超出了二维码的容量.
+	IMAGE_QR_CODE_ERROR = 4000015
+
 	// cmd 执行命令失败.
 	CMD_COMMAND_RUN_ERROR = 5000001
 
 	// 布局类型查找失败.
 	LAYOUT_TYPE_NOT_FIND_ERROR = 6000001
 
+	// 布局中的文字表达式错误.
+	LAYOUT_TEXT_TEMPLATE_ERROR = 6000002
+
+	// 相机与镜头名称映射文件错误.
+	LAYOUT_DISPLAY_NAME_ERROR = 6000003
+
+	// 离线地名数据库格式错误.
+	LAYOUT_PLACE_DATABASE_ERROR = 6000004
+
 	// 内部错误.
 	INTERNAL_ERROR = 9000001
 
@@ -30,6 +30,12 @@ var (
 		Error: errors.New("exiftool工具不存在,请检查是否安装"),
 	}
 
+	// ImageMagick工具不存在.
+	ImageMagickNotExistError = EError{
+		Code:  IMAGEMAGICK_NOTEXIST_ERROR,
+		Error: errors.New("ImageMagick工具不存在,请检查是否安装"),
+	}
+
 	// exiftool工具init失败.
 	ExiftoolInitError = EError{
 		Code:  EXIFTOOL_INIT_ERROR,
@@ -0,0 +1,91 @@
+package pkg
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/disintegration/imaging"
+)
+
+// 线性值转换为编码值时查找表的大小,暗部需要更高的精度.
+const colorTransformOutputSize = 16384
+
+// 色彩配置文件之间的转换.
+type colorTransform struct {
+	// 编码值转换为线性值.
+	input [3][256]float64
+	// 线性值转换为编码值.
+	output [3][]uint8
+	// 源线性RGB转换为目标线性RGB的矩阵.
+	matrix [3][3]float64
+}
+
+// 创建色彩转换,使用查找表加速逐像素转换.
+func newColorTransform(src, dst *ColorProfile) *colorTransform {
+	t := &colorTransform{
+		matrix: mulMatrix(invertMatrix(dst.toXYZ), src.toXYZ),
+	}
+	for c := range 3 {
+		for i := range 256 {
+			t.input[c][i] = src.curves[c].eval(float64(i) / 255)
+		}
+		t.output[c] = make([]uint8, colorTransformOutputSize)
+		for i := range colorTransformOutputSize {
+			v := dst.curves[c].inverse(float64(i) / (colorTransformOutputSize - 1))
+			t.output[c][i] = uint8(math.Round(v * 255))
+		}
+	}
+
+	return t
+}
+
+// 转换一个像素.
+func (t *colorTransform) apply(r, g, b uint8) (uint8, uint8, uint8) {
+	linear := mulMatrixVector(t.matrix, [3]float64{t.input[0][r], t.input[1][g], t.input[2][b]})
+	var out [3]uint8
+	for c := range 3 {
+		i := int(math.Round(min(max(linear[c], 0), 1) * (colorTransformOutputSize - 1)))
+		out[c] = t.output[c][i]
+	}
+
+	return out[0], out[1], out[2]
+}
+
+// 将图片从源色彩空间转换到目标色彩空间,两者一致时直接返回原图片.
+func ConvertImageColorProfile(img image.Image, src, dst *ColorProfile) image.Image {
+	if src == nil || dst == nil || src.Equal(dst) {
+		return img
+	}
+	t := newColorTransform(src, dst)
+	out := imaging.Clone(img)
+	ParallelRows(out.Bounds().Dy(), func(startRow, endRow int) {
+		for y := startRow; y < endRow; y++ {
+			row := out.Pix[y*out.Stride : y*out.Stride+out.Bounds().Dx()*4]
+			for x := 0; x < len(row); x += 4 {
+				row[x], row[x+1], row[x+2] = t.apply(row[x], row[x+1], row[x+2])
+			}
+		}
+	})
+
+	return out
+}
+
+// 转换单个颜色,透明度保持不变.
+func ConvertColorProfile(c color.RGBA, src, dst *ColorProfile) color.RGBA {
+	if src == nil || dst == nil || src.Equal(dst) {
+		return c
+	}
+	in := [3]float64{
+		src.curves[0].eval(float64(c.R) / 255),
+		src.curves[1].eval(float64(c.G) / 255),
+		src.curves[2].eval(float64(c.B) / 255),
+	}
+	linear := mulMatrixVector(mulMatrix(invertMatrix(dst.toXYZ), src.toXYZ), in)
+	var out [3]uint8
+	for i := range 3 {
+		out[i] = uint8(math.Round(dst.curves[i].inverse(min(max(linear[i], 0), 1)) * 255))
+	}
+
+	return color.RGBA{R: out[0], G: out[1], B: out[2], A: c.A}
+}
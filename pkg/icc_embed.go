@@ -0,0 +1,205 @@
+package pkg
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+)
+
+const (
+	// jpg中ICC配置文件APP2段的标识.
+	jpegICCMarker = "ICC_PROFILE\x00"
+
+	// jpg单个APP2段最多能够存放的ICC数据长度.
+	jpegICCChunkSize = 65535 - 2 - len(jpegICCMarker) - 2
+
+	// png文件签名长度.
+	pngSignatureSize = 8
+
+	// png文件签名与IHDR块的总长度.
+	pngIHDREnd = pngSignatureSize + 4 + 4 + 13 + 4
+)
+
+// 读取图片文件中嵌入的ICC配置文件,支持jpg与png,没有嵌入时返回nil.
+func ReadColorProfileFromFile(path string) ([]byte, EError) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, NewErrors(FILE_NOT_OPEN_ERROR, path+":文件打开失败:"+err.Error())
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	magic, err := reader.Peek(pngSignatureSize)
+	if err != nil {
+		return nil, NoError
+	}
+	var data []byte
+	switch {
+	case magic[0] == 0xFF && magic[1] == 0xD8:
+		data, err = readJpegColorProfile(reader)
+	case string(magic) == "\x89PNG\r\n\x1a\n":
+		data, err = readPngColorProfile(reader)
+	}
+	if err != nil {
+		return nil, NewErrors(IMAGE_ICC_PROFILE_ERROR, path+":读取ICC配置文件失败:"+err.Error())
+	}
+
+	return data, NoError
+}
+
+// 读取jpg的APP2段中的ICC配置文件,配置文件可能被拆分为多个段.
+func readJpegColorProfile(reader *bufio.Reader) ([]byte, error) {
+	if _, err := reader.Discard(2); err != nil {
+		return nil, err
+	}
+	chunks := make(map[byte][]byte)
+	var total byte
+	for {
+		marker := make([]byte, 4)
+		if _, err := io.ReadFull(reader, marker); err != nil {
+			return nil, err
+		}
+		// 到达图像数据或者格式不正确时结束查找
+		if marker[0] != 0xFF || marker[1] == 0xDA || marker[1] == 0xD9 {
+			break
+		}
+		length := int(binary.BigEndian.Uint16(marker[2:])) - 2
+		if length < 0 {
+			break
+		}
+		if marker[1] != 0xE2 || length < len(jpegICCMarker)+2 {
+			if _, err := reader.Discard(length); err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+		segment := make([]byte, length)
+		if _, err := io.ReadFull(reader, segment); err != nil {
+			return nil, err
+		}
+		if string(segment[:len(jpegICCMarker)]) != jpegICCMarker {
+			continue
+		}
+		seq := segment[len(jpegICCMarker)]
+		total = segment[len(jpegICCMarker)+1]
+		chunks[seq] = segment[len(jpegICCMarker)+2:]
+	}
+	if len(chunks) == 0 || len(chunks) != int(total) {
+		return nil, nil
+	}
+	var buf bytes.Buffer
+	for i := 1; i <= int(total); i++ {
+		buf.Write(chunks[byte(i)])
+	}
+
+	return buf.Bytes(), nil
+}
+
+// 读取png的iCCP块中的ICC配置文件.
+func readPngColorProfile(reader *bufio.Reader) ([]byte, error) {
+	if _, err := reader.Discard(pngSignatureSize); err != nil {
+		return nil, err
+	}
+	for {
+		header := make([]byte, 8)
+		if _, err := io.ReadFull(reader, header); err != nil {
+			return nil, err
+		}
+		length := int(binary.BigEndian.Uint32(header))
+		switch string(header[4:]) {
+		case "IDAT", "IEND":
+			return nil, nil
+		case "iCCP":
+			chunk := make([]byte, length)
+			if _, err := io.ReadFull(reader, chunk); err != nil {
+				return nil, err
+			}
+			// 配置文件名称以0结尾,之后是1字节的压缩方式
+			nameEnd := bytes.IndexByte(chunk, 0)
+			if nameEnd < 0 || nameEnd+2 > len(chunk) {
+				return nil, nil
+			}
+			zr, err := zlib.NewReader(bytes.NewReader(chunk[nameEnd+2:]))
+			if err != nil {
+				return nil, err
+			}
+			defer zr.Close()
+
+			return io.ReadAll(zr)
+		default:
+			if _, err := reader.Discard(length + 4); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// 编码jpg图片并嵌入ICC配置文件.
+func EncodeJpegWithColorProfile(w io.Writer, img image.Image, quality int, profile []byte) error {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return err
+	}
+	data := buf.Bytes()
+	if len(profile) == 0 {
+		_, err := w.Write(data)
+
+		return err
+	}
+	// APP2段放在SOI之后
+	segments := bytes.NewBuffer(data[:2:2])
+	count := (len(profile) + jpegICCChunkSize - 1) / jpegICCChunkSize
+	for i := range count {
+		chunk := profile[i*jpegICCChunkSize : min((i+1)*jpegICCChunkSize, len(profile))]
+		segments.Write([]byte{0xFF, 0xE2})
+		_ = binary.Write(segments, binary.BigEndian, uint16(2+len(jpegICCMarker)+2+len(chunk)))
+		segments.WriteString(jpegICCMarker)
+		segments.Write([]byte{byte(i + 1), byte(count)})
+		segments.Write(chunk)
+	}
+	segments.Write(data[2:])
+	_, err := w.Write(segments.Bytes())
+
+	return err
+}
+
+// 编码png图片并嵌入ICC配置文件.
+func EncodePngWithColorProfile(w io.Writer, img image.Image, level png.CompressionLevel, profile []byte) error {
+	var buf bytes.Buffer
+	encoder := png.Encoder{CompressionLevel: level}
+	if err := encoder.Encode(&buf, img); err != nil {
+		return err
+	}
+	data := buf.Bytes()
+	if len(profile) == 0 {
+		_, err := w.Write(data)
+
+		return err
+	}
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	if _, err := zw.Write(profile); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	// iCCP块需要放在IHDR之后,IDAT之前
+	chunk := append([]byte("iCCPICC Profile\x00\x00"), compressed.Bytes()...)
+	out := bytes.NewBuffer(data[:pngIHDREnd:pngIHDREnd])
+	_ = binary.Write(out, binary.BigEndian, uint32(len(chunk)-4))
+	out.Write(chunk)
+	_ = binary.Write(out, binary.BigEndian, crc32.ChecksumIEEE(chunk))
+	out.Write(data[pngIHDREnd:])
+	_, err := w.Write(out.Bytes())
+
+	return err
+}
@@ -0,0 +1,517 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"sync"
+)
+
+const (
+	// 色彩空间:sRGB.
+	COLOR_SPACE_SRGB = "srgb"
+
+	// 色彩空间:Display P3.
+	COLOR_SPACE_DISPLAY_P3 = "display-p3"
+
+	// 色彩空间:Adobe RGB (1998).
+	COLOR_SPACE_ADOBE_RGB = "adobe-rgb"
+
+	// ICC文件头长度.
+	iccHeaderSize = 128
+
+	// s15Fixed16Number的精度.
+	iccFixed16 = 65536.0
+)
+
+type (
+	// 色调曲线,将编码值(0~1)转换为线性值.
+	toneCurve struct {
+		// curv类型的采样表.
+		table []float64
+		// para类型的参数.
+		params []float64
+		// para类型的函数类型.
+		funcType int
+		// curv类型的gamma值.
+		gamma float64
+	}
+
+	// ICC色彩配置文件,只支持矩阵/TRC类型的RGB配置文件.
+	ColorProfile struct {
+		// 配置文件名称.
+		Name string
+		// 配置文件原始数据,用于嵌入到导出的图片中.
+		Data []byte
+		// 线性RGB转换为PCS(D50 XYZ)的矩阵.
+		toXYZ [3][3]float64
+		// 红绿蓝三个通道的色调曲线.
+		curves [3]toneCurve
+	}
+
+	// 内置色彩空间的参数.
+	colorSpacePrimaries struct {
+		desc  string
+		red   [2]float64
+		green [2]float64
+		blue  [2]float64
+		// 为0时使用sRGB曲线.
+		gamma float64
+	}
+)
+
+var (
+	// 内置色彩空间参数,白点均为D65.
+	builtinColorSpaces = map[string]colorSpacePrimaries{
+		COLOR_SPACE_SRGB: {
+			desc: "sRGB", red: [2]float64{0.64, 0.33}, green: [2]float64{0.30, 0.60}, blue: [2]float64{0.15, 0.06},
+		},
+		COLOR_SPACE_DISPLAY_P3: {
+			desc: "Display P3", red: [2]float64{0.680, 0.320}, green: [2]float64{0.265, 0.690},
+			blue: [2]float64{0.150, 0.060},
+		},
+		COLOR_SPACE_ADOBE_RGB: {
+			desc: "Adobe RGB (1998)", red: [2]float64{0.64, 0.33}, green: [2]float64{0.21, 0.71},
+			blue: [2]float64{0.15, 0.06}, gamma: 563.0 / 256.0,
+		},
+	}
+
+	// 已经生成的内置色彩配置文件.
+	builtinColorProfiles    = make(map[string]*ColorProfile)
+	builtinColorProfilesMtx sync.Mutex
+
+	// D50白点,ICC的PCS白点.
+	whitePointD50 = [3]float64{0.9642, 1.0, 0.8249}
+
+	// D65白点的xy坐标.
+	whitePointD65 = [2]float64{0.3127, 0.3290}
+
+	// sRGB色调曲线参数,para类型3.
+	srgbCurveParams = []float64{2.4, 1 / 1.055, 0.055 / 1.055, 1 / 12.92, 0.04045}
+)
+
+// 计算曲线的值,输入与输出范围均为0~1.
+func (c *toneCurve) eval(x float64) float64 {
+	x = min(max(x, 0), 1)
+	var y float64
+	switch {
+	case c.table != nil:
+		pos := x * float64(len(c.table)-1)
+		i := int(pos)
+		if i >= len(c.table)-1 {
+			return c.table[len(c.table)-1]
+		}
+		y = c.table[i] + (c.table[i+1]-c.table[i])*(pos-float64(i))
+	case c.params != nil:
+		y = evalParametricCurve(c.funcType, c.params, x)
+	default:
+		y = math.Pow(x, c.gamma)
+	}
+
+	return min(max(y, 0), 1)
+}
+
+// 计算曲线的反函数,曲线单调递增,使用二分查找.
+func (c *toneCurve) inverse(y float64) float64 {
+	lo, hi := 0.0, 1.0
+	for range 24 {
+		mid := (lo + hi) / 2
+		if c.eval(mid) < y {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+
+	return (lo + hi) / 2
+}
+
+// 计算para类型曲线的值.
+func evalParametricCurve(funcType int, p []float64, x float64) float64 {
+	g := p[0]
+	switch funcType {
+	case 1:
+		if x >= -p[2]/p[1] {
+			return math.Pow(p[1]*x+p[2], g)
+		}
+
+		return 0
+	case 2:
+		if x >= -p[2]/p[1] {
+			return math.Pow(p[1]*x+p[2], g) + p[3]
+		}
+
+		return p[3]
+	case 3:
+		if x >= p[4] {
+			return math.Pow(p[1]*x+p[2], g)
+		}
+
+		return p[3] * x
+	case 4:
+		if x >= p[4] {
+			return math.Pow(p[1]*x+p[2], g) + p[5]
+		}
+
+		return p[3]*x + p[6]
+	default:
+		return math.Pow(x, g)
+	}
+}
+
+// 解析ICC色彩配置文件.
+func ParseColorProfile(data []byte) (*ColorProfile, EError) {
+	if len(data) < iccHeaderSize+4 || string(data[36:40]) != "acsp" {
+		return nil, NewErrors(IMAGE_ICC_PROFILE_ERROR, "ICC配置文件格式错误")
+	}
+	if string(data[16:20]) != "RGB " || string(data[20:24]) != "XYZ " {
+		return nil, NewErrors(IMAGE_ICC_PROFILE_ERROR, "只支持RGB色彩空间的矩阵类型ICC配置文件")
+	}
+	tags := readColorProfileTags(data)
+	profile := &ColorProfile{Data: data, Name: readColorProfileDesc(tags["desc"])}
+	for i, sig := range []string{"rXYZ", "gXYZ", "bXYZ"} {
+		xyz, ok := readXYZTag(tags[sig])
+		if !ok {
+			return nil, NewErrors(IMAGE_ICC_PROFILE_ERROR, "ICC配置文件缺少"+sig+"信息")
+		}
+		for row := range 3 {
+			profile.toXYZ[row][i] = xyz[row]
+		}
+	}
+	for i, sig := range []string{"rTRC", "gTRC", "bTRC"} {
+		curve, ok := readCurveTag(tags[sig])
+		if !ok {
+			return nil, NewErrors(IMAGE_ICC_PROFILE_ERROR, "ICC配置文件缺少"+sig+"信息")
+		}
+		profile.curves[i] = curve
+	}
+
+	return profile, NoError
+}
+
+// 读取ICC配置文件的标签表.
+func readColorProfileTags(data []byte) map[string][]byte {
+	tags := make(map[string][]byte)
+	count := int(binary.BigEndian.Uint32(data[iccHeaderSize:]))
+	for i := range count {
+		entry := iccHeaderSize + 4 + i*12
+		if entry+12 > len(data) {
+			break
+		}
+		offset := int(binary.BigEndian.Uint32(data[entry+4:]))
+		size := int(binary.BigEndian.Uint32(data[entry+8:]))
+		if offset < 0 || size < 0 || offset+size > len(data) {
+			continue
+		}
+		tags[string(data[entry:entry+4])] = data[offset : offset+size]
+	}
+
+	return tags
+}
+
+// 读取配置文件描述,兼容v2的desc类型与v4的mluc类型.
+func readColorProfileDesc(tag []byte) string {
+	if len(tag) < 12 {
+		return ""
+	}
+	switch string(tag[0:4]) {
+	case "desc":
+		n := int(binary.BigEndian.Uint32(tag[8:]))
+		if 12+n > len(tag) {
+			return ""
+		}
+
+		return string(bytes.TrimRight(tag[12:12+n], "\x00"))
+	case "mluc":
+		if len(tag) < 28 {
+			return ""
+		}
+		size := int(binary.BigEndian.Uint32(tag[20:]))
+		offset := int(binary.BigEndian.Uint32(tag[24:]))
+		if offset+size > len(tag) {
+			return ""
+		}
+		runes := make([]rune, 0, size/2)
+		for i := offset; i+1 < offset+size; i += 2 {
+			runes = append(runes, rune(binary.BigEndian.Uint16(tag[i:])))
+		}
+
+		return string(runes)
+	default:
+		return ""
+	}
+}
+
+// 读取XYZ类型的标签.
+func readXYZTag(tag []byte) ([3]float64, bool) {
+	var xyz [3]float64
+	if len(tag) < 20 || string(tag[0:4]) != "XYZ " {
+		return xyz, false
+	}
+	for i := range 3 {
+		xyz[i] = float64(int32(binary.BigEndian.Uint32(tag[8+i*4:]))) / iccFixed16
+	}
+
+	return xyz, true
+}
+
+// 读取curv或para类型的色调曲线标签.
+func readCurveTag(tag []byte) (toneCurve, bool) {
+	if len(tag) < 12 {
+		return toneCurve{}, false
+	}
+	switch string(tag[0:4]) {
+	case "curv":
+		count := int(binary.BigEndian.Uint32(tag[8:]))
+		if len(tag) < 12+count*2 {
+			return toneCurve{}, false
+		}
+		switch count {
+		case 0:
+			return toneCurve{gamma: 1}, true
+		case 1:
+			return toneCurve{gamma: float64(binary.BigEndian.Uint16(tag[12:])) / 256}, true
+		}
+		table := make([]float64, count)
+		for i := range table {
+			table[i] = float64(binary.BigEndian.Uint16(tag[12+i*2:])) / 65535
+		}
+
+		return toneCurve{table: table}, true
+	case "para":
+		funcType := int(binary.BigEndian.Uint16(tag[8:]))
+		paramCounts := []int{1, 3, 4, 5, 7}
+		if funcType >= len(paramCounts) || len(tag) < 12+paramCounts[funcType]*4 {
+			return toneCurve{}, false
+		}
+		params := make([]float64, paramCounts[funcType])
+		for i := range params {
+			params[i] = float64(int32(binary.BigEndian.Uint32(tag[12+i*4:]))) / iccFixed16
+		}
+
+		return toneCurve{params: params, funcType: funcType}, true
+	default:
+		return toneCurve{}, false
+	}
+}
+
+// 获取内置的色彩配置文件,支持srgb,display-p3,adobe-rgb.
+func GetColorProfile(name string) (*ColorProfile, EError) {
+	builtinColorProfilesMtx.Lock()
+	defer builtinColorProfilesMtx.Unlock()
+
+	if profile, ok := builtinColorProfiles[name]; ok {
+		return profile, NoError
+	}
+	primaries, ok := builtinColorSpaces[name]
+	if !ok {
+		return nil, NewErrors(IMAGE_ICC_PROFILE_ERROR, "不支持的色彩空间:"+name)
+	}
+	// 生成配置文件之后再解析,保证嵌入文件与转换时使用的参数一致
+	profile, err := ParseColorProfile(buildColorProfileData(primaries))
+	if HasError(err) {
+		return nil, err
+	}
+	builtinColorProfiles[name] = profile
+
+	return profile, NoError
+}
+
+// 获取sRGB色彩配置文件,没有嵌入配置文件的图片按照sRGB处理.
+func GetSRGBColorProfile() *ColorProfile {
+	profile, _ := GetColorProfile(COLOR_SPACE_SRGB)
+
+	return profile
+}
+
+// 判断两个配置文件的转换结果是否一致,一致时不需要转换.
+func (p *ColorProfile) Equal(other *ColorProfile) bool {
+	if p == other {
+		return true
+	}
+	for row := range 3 {
+		for col := range 3 {
+			if math.Abs(p.toXYZ[row][col]-other.toXYZ[row][col]) > 0.002 {
+				return false
+			}
+		}
+	}
+	for i := range 3 {
+		for x := 0.05; x < 1; x += 0.1 {
+			if math.Abs(p.curves[i].eval(x)-other.curves[i].eval(x)) > 0.002 {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// 生成ICC v2版本的矩阵类型配置文件数据.
+func buildColorProfileData(primaries colorSpacePrimaries) []byte {
+	matrix := primariesToXYZD50(primaries)
+	var curve []byte
+	if primaries.gamma > 0 {
+		curve = buildCurveTag([]uint16{uint16(math.Round(primaries.gamma * 256))})
+	} else {
+		srgb := toneCurve{params: srgbCurveParams, funcType: 3}
+		table := make([]uint16, 1024)
+		for i := range table {
+			table[i] = uint16(math.Round(srgb.eval(float64(i)/1023) * 65535))
+		}
+		curve = buildCurveTag(table)
+	}
+	tags := []struct {
+		sig  string
+		data []byte
+	}{
+		{"desc", buildDescTag(primaries.desc)},
+		{"cprt", buildTextTag("No copyright, use freely")},
+		{"wtpt", buildXYZTag(whitePointD50)},
+		{"rXYZ", buildXYZTag([3]float64{matrix[0][0], matrix[1][0], matrix[2][0]})},
+		{"gXYZ", buildXYZTag([3]float64{matrix[0][1], matrix[1][1], matrix[2][1]})},
+		{"bXYZ", buildXYZTag([3]float64{matrix[0][2], matrix[1][2], matrix[2][2]})},
+		{"rTRC", curve},
+		{"gTRC", curve},
+		{"bTRC", curve},
+	}
+
+	var table, body bytes.Buffer
+	offset := iccHeaderSize + 4 + len(tags)*12
+	_ = binary.Write(&table, binary.BigEndian, uint32(len(tags)))
+	for _, tag := range tags {
+		_ = binary.Write(&table, binary.BigEndian, []byte(tag.sig))
+		_ = binary.Write(&table, binary.BigEndian, []uint32{uint32(offset + body.Len()), uint32(len(tag.data))})
+		body.Write(tag.data)
+		// 标签数据按照4字节对齐
+		for body.Len()%4 != 0 {
+			body.WriteByte(0)
+		}
+	}
+
+	header := make([]byte, iccHeaderSize)
+	binary.BigEndian.PutUint32(header[0:], uint32(offset+body.Len()))
+	binary.BigEndian.PutUint32(header[8:], 0x02100000)
+	copy(header[12:], "mntrRGB XYZ ")
+	copy(header[36:], "acsp")
+	copy(header[68:], buildXYZTag(whitePointD50)[8:])
+
+	return bytes.Join([][]byte{header, table.Bytes(), body.Bytes()}, nil)
+}
+
+// 生成desc类型标签.
+func buildDescTag(desc string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("desc")
+	_ = binary.Write(&buf, binary.BigEndian, []uint32{0, uint32(len(desc) + 1)})
+	buf.WriteString(desc)
+	buf.WriteByte(0)
+	// unicode与scriptcode描述为空
+	buf.Write(make([]byte, 4+4+2+1+67))
+
+	return buf.Bytes()
+}
+
+// 生成text类型标签.
+func buildTextTag(text string) []byte {
+	return append(append([]byte("text\x00\x00\x00\x00"), text...), 0)
+}
+
+// 生成XYZ类型标签.
+func buildXYZTag(xyz [3]float64) []byte {
+	data := make([]byte, 20)
+	copy(data, "XYZ ")
+	for i, v := range xyz {
+		binary.BigEndian.PutUint32(data[8+i*4:], uint32(int32(math.Round(v*iccFixed16))))
+	}
+
+	return data
+}
+
+// 生成curv类型标签.
+func buildCurveTag(table []uint16) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("curv")
+	_ = binary.Write(&buf, binary.BigEndian, []uint32{0, uint32(len(table))})
+	_ = binary.Write(&buf, binary.BigEndian, table)
+
+	return buf.Bytes()
+}
+
+// 根据三原色与D65白点计算线性RGB转换为D50 XYZ的矩阵,使用Bradford进行白点适配.
+func primariesToXYZD50(primaries colorSpacePrimaries) [3][3]float64 {
+	xyY := func(xy [2]float64) [3]float64 {
+		return [3]float64{xy[0] / xy[1], 1, (1 - xy[0] - xy[1]) / xy[1]}
+	}
+	r, g, b := xyY(primaries.red), xyY(primaries.green), xyY(primaries.blue)
+	m := [3][3]float64{{r[0], g[0], b[0]}, {r[1], g[1], b[1]}, {r[2], g[2], b[2]}}
+	white := xyY(whitePointD65)
+	s := mulMatrixVector(invertMatrix(m), white)
+	for row := range 3 {
+		for col := range 3 {
+			m[row][col] *= s[col]
+		}
+	}
+	bradford := [3][3]float64{
+		{0.8951, 0.2664, -0.1614},
+		{-0.7502, 1.7135, 0.0367},
+		{0.0389, -0.0685, 1.0296},
+	}
+	src := mulMatrixVector(bradford, white)
+	dst := mulMatrixVector(bradford, whitePointD50)
+	scale := [3][3]float64{{dst[0] / src[0], 0, 0}, {0, dst[1] / src[1], 0}, {0, 0, dst[2] / src[2]}}
+	adapt := mulMatrix(invertMatrix(bradford), mulMatrix(scale, bradford))
+
+	return mulMatrix(adapt, m)
+}
+
+// 3x3矩阵相乘.
+func mulMatrix(a, b [3][3]float64) [3][3]float64 {
+	var m [3][3]float64
+	for row := range 3 {
+		for col := range 3 {
+			for k := range 3 {
+				m[row][col] += a[row][k] * b[k][col]
+			}
+		}
+	}
+
+	return m
+}
+
+// 3x3矩阵与向量相乘.
+func mulMatrixVector(m [3][3]float64, v [3]float64) [3]float64 {
+	return [3]float64{
+		m[0][0]*v[0] + m[0][1]*v[1] + m[0][2]*v[2],
+		m[1][0]*v[0] + m[1][1]*v[1] + m[1][2]*v[2],
+		m[2][0]*v[0] + m[2][1]*v[1] + m[2][2]*v[2],
+	}
+}
+
+// 3x3矩阵求逆.
+func invertMatrix(m [3][3]float64) [3][3]float64 {
+	det := m[0][0]*(m[1][1]*m[2][2]-m[1][2]*m[2][1]) -
+		m[0][1]*(m[1][0]*m[2][2]-m[1][2]*m[2][0]) +
+		m[0][2]*(m[1][0]*m[2][1]-m[1][1]*m[2][0])
+	if det == 0 {
+		return m
+	}
+
+	return [3][3]float64{
+		{
+			(m[1][1]*m[2][2] - m[1][2]*m[2][1]) / det,
+			(m[0][2]*m[2][1] - m[0][1]*m[2][2]) / det,
+			(m[0][1]*m[1][2] - m[0][2]*m[1][1]) / det,
+		},
+		{
+			(m[1][2]*m[2][0] - m[1][0]*m[2][2]) / det,
+			(m[0][0]*m[2][2] - m[0][2]*m[2][0]) / det,
+			(m[0][2]*m[1][0] - m[0][0]*m[1][2]) / det,
+		},
+		{
+			(m[1][0]*m[2][1] - m[1][1]*m[2][0]) / det,
+			(m[0][1]*m[2][0] - m[0][0]*m[2][1]) / det,
+			(m[0][0]*m[1][1] - m[0][1]*m[1][0]) / det,
+		},
+	}
+}
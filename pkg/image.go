@@ -6,26 +6,70 @@ import (
 	"image/png"
 	"net/http"
 	"os"
+	"path/filepath"
 	"runtime"
+	"slices"
+	"strings"
 	"sync"
 
 	"github.com/disintegration/imaging"
 	"github.com/nfnt/resize"
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/tiff"
+	"golang.org/x/image/webp"
 )
 
-// 加载图片.
-func LoadImageWithDecode(path string) (image.Image, EError) {
-	rio, err := os.Open(path)
-	if err != nil {
-		errmsg := path + ":文件打开失败:" + err.Error()
+const (
+	// 图片格式:jpeg.
+	IMAGE_FORMAT_JPEG = "jpeg"
 
-		return nil, NewErrors(FILE_NOT_OPEN_ERROR, errmsg)
+	// 图片格式:png.
+	IMAGE_FORMAT_PNG = "png"
+
+	// 图片格式:bmp.
+	IMAGE_FORMAT_BMP = "bmp"
+
+	// 图片格式:webp.
+	IMAGE_FORMAT_WEBP = "webp"
+
+	// 图片格式:tiff.
+	IMAGE_FORMAT_TIFF = "tiff"
+
+	// 图片格式:heic/heif.
+	IMAGE_FORMAT_HEIF = "heif"
+
+	// 图片格式:avif.
+	IMAGE_FORMAT_AVIF = "avif"
+)
+
+var (
+	// 支持导入的图片后缀名.
+	SupportImageExts = []string{".jpg", ".jpeg", ".png", ".tif", ".tiff", ".bmp", ".webp", ".heic", ".heif", ".avif"}
+
+	// 支持导入的RAW文件后缀名,使用内嵌的预览图片生成边框.
+	RawImageExts = []string{".nef", ".nrw", ".cr2", ".cr3", ".arw", ".raf", ".orf", ".dng"}
+
+	// heif容器的品牌标识.
+	heifBrands = map[string]string{
+		"heic": IMAGE_FORMAT_HEIF,
+		"heix": IMAGE_FORMAT_HEIF,
+		"hevc": IMAGE_FORMAT_HEIF,
+		"hevx": IMAGE_FORMAT_HEIF,
+		"heim": IMAGE_FORMAT_HEIF,
+		"heis": IMAGE_FORMAT_HEIF,
+		"mif1": IMAGE_FORMAT_HEIF,
+		"msf1": IMAGE_FORMAT_HEIF,
+		"avif": IMAGE_FORMAT_AVIF,
+		"avis": IMAGE_FORMAT_AVIF,
 	}
-	filetype, eErr := GetFileType(rio)
+)
+
+// 加载图片,支持jpeg,png,bmp,webp,tiff格式.
+func LoadImageWithDecode(path string) (image.Image, EError) {
+	format, eErr := GetImageFormat(path)
 	if HasError(eErr) {
 		return nil, eErr
 	}
-	rio.Close()
 
 	io, err := os.Open(path)
 	if err != nil {
@@ -36,11 +80,17 @@ func LoadImageWithDecode(path string) (image.Image, EError) {
 	defer io.Close()
 
 	var img image.Image
-	switch filetype {
-	case "image/jpeg", "image/jpg":
+	switch format {
+	case IMAGE_FORMAT_JPEG:
 		img, err = jpeg.Decode(io)
-	case "image/png":
+	case IMAGE_FORMAT_PNG:
 		img, err = png.Decode(io)
+	case IMAGE_FORMAT_BMP:
+		img, err = bmp.Decode(io)
+	case IMAGE_FORMAT_WEBP:
+		img, err = webp.Decode(io)
+	case IMAGE_FORMAT_TIFF:
+		img, err = tiff.Decode(io)
 	default:
 		errmsg := path + ":文件不是支持的格式"
 
@@ -56,6 +106,62 @@ func LoadImageWithDecode(path string) (image.Image, EError) {
 	return img, NoError
 }
 
+// 根据文件头获取图片格式,无法识别时返回空字符串.
+func GetImageFormat(path string) (string, EError) {
+	rio, err := os.Open(path)
+	if err != nil {
+		errmsg := path + ":文件打开失败:" + err.Error()
+
+		return "", NewErrors(FILE_NOT_OPEN_ERROR, errmsg)
+	}
+	defer rio.Close()
+
+	filetype, eErr := GetFileType(rio)
+	if HasError(eErr) {
+		return "", eErr
+	}
+	switch filetype {
+	case "image/jpeg", "image/jpg":
+		return IMAGE_FORMAT_JPEG, NoError
+	case "image/png":
+		return IMAGE_FORMAT_PNG, NoError
+	case "image/bmp":
+		return IMAGE_FORMAT_BMP, NoError
+	case "image/webp":
+		return IMAGE_FORMAT_WEBP, NoError
+	}
+	// http库无法识别tiff与heif容器,读取文件头判断
+	header := make([]byte, 12)
+	if _, err = rio.ReadAt(header, 0); err != nil {
+		return "", NoError
+	}
+	if string(header[:4]) == "II*\x00" || string(header[:4]) == "MM\x00*" {
+		return IMAGE_FORMAT_TIFF, NoError
+	}
+	if string(header[4:8]) == "ftyp" {
+		return heifBrands[string(header[8:12])], NoError
+	}
+
+	return "", NoError
+}
+
+// 是否需要使用ImageMagick转换之后才能解码.
+func IsMagickConvertFormat(format string) bool {
+	return format == IMAGE_FORMAT_HEIF || format == IMAGE_FORMAT_AVIF
+}
+
+// 是否是支持导入的图片文件,包含RAW文件.
+func IsSupportImageFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+
+	return slices.Contains(SupportImageExts, ext) || slices.Contains(RawImageExts, ext)
+}
+
+// 是否是RAW文件.
+func IsRawImageFile(path string) bool {
+	return slices.Contains(RawImageExts, strings.ToLower(filepath.Ext(path)))
+}
+
 // 获取文件类型.
 func GetFileType(io *os.File) (string, EError) {
 	buff := make([]byte, 512)
@@ -124,6 +230,27 @@ func ImageToRGBA(img image.Image) *image.RGBA {
 	return rgba
 }
 
+// 按照CPU数量将行拆分之后并行处理,fn处理[startRow,endRow)范围内的行.
+func ParallelRows(rows int, fn func(startRow, endRow int)) {
+	numGoroutines := max(min(runtime.NumCPU(), rows), 1)
+	rowsPerGoroutine := (rows + numGoroutines - 1) / numGoroutines
+
+	var wg sync.WaitGroup
+	for i := range numGoroutines {
+		startRow := i * rowsPerGoroutine
+		endRow := min(startRow+rowsPerGoroutine, rows)
+		if startRow >= endRow {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fn(startRow, endRow)
+		}()
+	}
+	wg.Wait()
+}
+
 // 并行顺时针旋转90度.
 func Rotate90(img *image.RGBA) *image.RGBA {
 	bounds := img.Bounds()
@@ -0,0 +1,404 @@
+package pkg
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"hash/fnv"
+	"image"
+	"image/draw"
+	"math"
+	"math/rand/v2"
+)
+
+const (
+	// 计算隐形水印时亮度缩放到的边长,与图片尺寸无关,缩放之后的图片仍然可以读取.
+	INVISIBLE_WATERMARK_SIZE = 512
+
+	// 隐形水印DCT分块的边长.
+	INVISIBLE_WATERMARK_BLOCK = 8
+
+	// DCT系数量化的步长,越大越不容易被压缩与缩放破坏,也越容易被看出来.
+	INVISIBLE_WATERMARK_STEP = 16.0
+
+	// 嵌入水印的次数,缩放到固定尺寸再插值回原尺寸会损失一部分调整量,需要按照实际结果再次修正.
+	INVISIBLE_WATERMARK_PASSES = 2
+
+	// 隐形水印数据的标识.
+	INVISIBLE_WATERMARK_MAGIC = 0x574d
+
+	// 隐形水印数据的长度:标识2字节,所有者4字节,导出时间4字节,校验2字节.
+	INVISIBLE_WATERMARK_BYTES = 12
+
+	// 整张图片的水印层,导出的图片没有裁剪时读取.
+	INVISIBLE_WATERMARK_LAYER_IMAGE = "image"
+
+	// 照片区域的水印层,裁剪掉边框之后读取.
+	INVISIBLE_WATERMARK_LAYER_PHOTO = "photo"
+)
+
+type (
+	// 隐形水印携带的数据.
+	InvisibleWatermark struct {
+		// 读取到水印的层
+		Layer string
+		// 所有者ID的hash
+		OwnerHash uint32
+		// 导出时间的时间戳,单位秒
+		Timestamp int64
+		// 与读取结果一致的系数比例,范围0-1
+		Agreement float64
+	}
+
+	// 隐形水印的嵌入层,不同的层使用不同的DCT系数与分块顺序,互相之间只是噪声.
+	invisibleWatermarkLayer struct {
+		name string
+		// 每个分块中嵌入水印的DCT系数,按照纵向频率与横向频率排列
+		coefficients [][2]int
+		seed         uint64
+	}
+
+	// 缩放到固定尺寸时原始位置的面积权重.
+	areaWeight struct {
+		index  int
+		weight float64
+	}
+
+	// 像素在固定尺寸中对应的两个相邻位置与插值权重.
+	canonicalSample struct {
+		start  int
+		end    int
+		weight float64
+	}
+)
+
+var (
+	// 隐形水印的全部嵌入层,照片区域最后嵌入,保证裁剪之后的读取效果.
+	invisibleWatermarkLayers = []invisibleWatermarkLayer{
+		{name: INVISIBLE_WATERMARK_LAYER_IMAGE, coefficients: [][2]int{{1, 2}, {2, 1}}, seed: 0x9e3779b97f4a7c15},
+		{name: INVISIBLE_WATERMARK_LAYER_PHOTO, coefficients: [][2]int{{2, 3}, {3, 2}}, seed: 0xc2b2ae3d27d4eb4f},
+	}
+
+	// DCT基函数,dctBasis[u][x]为频率u在位置x的值.
+	dctBasis = newDCTBasis()
+)
+
+// 获取所有者ID对应的hash,隐形水印中只保存hash.
+func InvisibleWatermarkOwnerHash(owner string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(owner))
+
+	return h.Sum32()
+}
+
+// 在图片中嵌入隐形水印,整张图片与照片区域各嵌入一份,裁剪掉边框之后仍然可以读取照片区域中的水印.
+func EmbedInvisibleWatermark(img draw.Image, photoRect image.Rectangle, mark InvisibleWatermark) draw.Image {
+	rgba, ok := img.(*image.RGBA)
+	if !ok {
+		rgba = image.NewRGBA(img.Bounds())
+		draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+	}
+	bits := encodeInvisibleWatermark(mark)
+	// 平铺水印的分块较小,先嵌入,之后按照固定尺寸嵌入的水印会修正平铺水印带来的影响
+	invisibleWatermarkTileLayer.embedTiles(rgba, photoRect.Intersect(rgba.Bounds()), bits)
+	for _, layer := range invisibleWatermarkLayers {
+		rect := rgba.Bounds()
+		if layer.name == INVISIBLE_WATERMARK_LAYER_PHOTO {
+			rect = photoRect.Intersect(rect)
+		}
+		layer.embed(rgba, rect, bits)
+	}
+
+	return rgba
+}
+
+// 读取图片中的隐形水印,依次尝试全部的嵌入层,没有找到时返回false.
+func ExtractInvisibleWatermark(img image.Image) (InvisibleWatermark, bool) {
+	if img.Bounds().Dx() < INVISIBLE_WATERMARK_BLOCK || img.Bounds().Dy() < INVISIBLE_WATERMARK_BLOCK {
+		return InvisibleWatermark{}, false
+	}
+	luminance := getCanonicalLuminance(img, img.Bounds())
+	for _, layer := range invisibleWatermarkLayers {
+		if mark, ok := layer.extract(luminance); ok {
+			return mark, true
+		}
+	}
+
+	// 裁剪的位置与照片区域不一致时,读取按照原始像素平铺的水印
+	return invisibleWatermarkTileLayer.extractTiles(img)
+}
+
+// 将水印数据转换为比特位,尾部为前面数据的校验值.
+func encodeInvisibleWatermark(mark InvisibleWatermark) []bool {
+	data := make([]byte, INVISIBLE_WATERMARK_BYTES)
+	binary.BigEndian.PutUint16(data, INVISIBLE_WATERMARK_MAGIC)
+	binary.BigEndian.PutUint32(data[2:], mark.OwnerHash)
+	binary.BigEndian.PutUint32(data[6:], uint32(mark.Timestamp))
+	binary.BigEndian.PutUint16(data[10:], uint16(crc32.ChecksumIEEE(data[:10])))
+	bits := make([]bool, len(data)*8)
+	for i := range bits {
+		bits[i] = data[i/8]>>(7-i%8)&1 == 1
+	}
+
+	return bits
+}
+
+// 将比特位还原为水印数据,标识或者校验值不一致时返回false.
+func decodeInvisibleWatermark(bits []bool) (InvisibleWatermark, bool) {
+	data := make([]byte, INVISIBLE_WATERMARK_BYTES)
+	for i, bit := range bits {
+		if bit {
+			data[i/8] |= 1 << (7 - i%8)
+		}
+	}
+	if binary.BigEndian.Uint16(data) != INVISIBLE_WATERMARK_MAGIC {
+		return InvisibleWatermark{}, false
+	}
+	if binary.BigEndian.Uint16(data[10:]) != uint16(crc32.ChecksumIEEE(data[:10])) {
+		return InvisibleWatermark{}, false
+	}
+
+	return InvisibleWatermark{
+		OwnerHash: binary.BigEndian.Uint32(data[2:]),
+		Timestamp: int64(binary.BigEndian.Uint32(data[6:])),
+	}, true
+}
+
+// 在图片的指定区域中嵌入水印,区域缩放到固定尺寸之后按照分块量化DCT系数.
+func (layer *invisibleWatermarkLayer) embed(img *image.RGBA, rect image.Rectangle, bits []bool) {
+	if rect.Dx() < INVISIBLE_WATERMARK_BLOCK || rect.Dy() < INVISIBLE_WATERMARK_BLOCK {
+		return
+	}
+	blockBits := layer.getBlockBits(getCanonicalBlockCount(), len(bits))
+	for range INVISIBLE_WATERMARK_PASSES {
+		luminance := getCanonicalLuminance(img, rect)
+		diff := make([]float64, len(luminance))
+		for block, bit := range blockBits {
+			for _, coefficient := range layer.coefficients {
+				change := getQuantizedChange(getDCTCoefficient(luminance, block, coefficient), bits[bit])
+				addDCTCoefficient(diff, block, coefficient, change)
+			}
+		}
+		applyCanonicalDifference(img, rect, diff)
+	}
+}
+
+// 读取水印,每个比特位由对应的全部分块中的系数按照可信度投票决定.
+func (layer *invisibleWatermarkLayer) extract(luminance []float64) (InvisibleWatermark, bool) {
+	bitCount := INVISIBLE_WATERMARK_BYTES * 8
+	blockBits := layer.getBlockBits(getCanonicalBlockCount(), bitCount)
+	votes := make([]float64, len(blockBits)*len(layer.coefficients))
+	sums := make([]float64, bitCount)
+	for block, bit := range blockBits {
+		for i, coefficient := range layer.coefficients {
+			vote := getQuantizedVote(getDCTCoefficient(luminance, block, coefficient))
+			votes[block*len(layer.coefficients)+i] = vote
+			sums[bit] += vote
+		}
+	}
+	bits := make([]bool, bitCount)
+	for i, sum := range sums {
+		bits[i] = sum > 0
+	}
+	mark, ok := decodeInvisibleWatermark(bits)
+	if !ok {
+		return mark, false
+	}
+	agreement := 0
+	for i, vote := range votes {
+		if (vote > 0) == bits[blockBits[i/len(layer.coefficients)]] {
+			agreement++
+		}
+	}
+	mark.Layer = layer.name
+	mark.Agreement = float64(agreement) / float64(len(votes))
+
+	return mark, true
+}
+
+// 获取每个分块对应的比特位,分块按照固定的随机顺序分配,同一个比特位的分块分散在整张图片中.
+func (layer *invisibleWatermarkLayer) getBlockBits(blockCount, bitCount int) []int {
+	order := rand.New(rand.NewPCG(layer.seed, INVISIBLE_WATERMARK_MAGIC)).Perm(blockCount)
+	for i := range order {
+		order[i] %= bitCount
+	}
+
+	return order
+}
+
+// 固定尺寸中分块的数量.
+func getCanonicalBlockCount() int {
+	blocks := INVISIBLE_WATERMARK_SIZE / INVISIBLE_WATERMARK_BLOCK
+
+	return blocks * blocks
+}
+
+// 按照量化索引调制计算系数需要调整的量,比特位为1时系数量化到半个步长的奇数倍,为0时量化到偶数倍.
+func getQuantizedChange(value float64, bit bool) float64 {
+	offset := 0.0
+	if bit {
+		offset = INVISIBLE_WATERMARK_STEP / 2
+	}
+
+	return math.Round((value-offset)/INVISIBLE_WATERMARK_STEP)*INVISIBLE_WATERMARK_STEP + offset - value
+}
+
+// 读取系数中的比特位,返回值为正数时比特位为1,绝对值为可信度,范围0-1.
+func getQuantizedVote(value float64) float64 {
+	half := value / (INVISIBLE_WATERMARK_STEP / 2)
+	index := math.Round(half)
+	confidence := 1 - 2*math.Abs(half-index)
+	if int(index)%2 == 0 {
+		return -confidence
+	}
+
+	return confidence
+}
+
+// 计算分块中指定位置的DCT系数.
+func getDCTCoefficient(luminance []float64, block int, coefficient [2]int) float64 {
+	var value float64
+	forEachBlockPixel(block, func(index, x, y int) {
+		value += luminance[index] * dctBasis[coefficient[0]][y] * dctBasis[coefficient[1]][x]
+	})
+
+	return value
+}
+
+// 分块中指定位置的DCT系数增加change,将对应的亮度变化累加到diff中.
+func addDCTCoefficient(diff []float64, block int, coefficient [2]int, change float64) {
+	forEachBlockPixel(block, func(index, x, y int) {
+		diff[index] += change * dctBasis[coefficient[0]][y] * dctBasis[coefficient[1]][x]
+	})
+}
+
+// 遍历分块中的全部像素,index为像素在固定尺寸亮度中的位置,x与y为像素在分块中的位置.
+func forEachBlockPixel(block int, fn func(index, x, y int)) {
+	blocks := INVISIBLE_WATERMARK_SIZE / INVISIBLE_WATERMARK_BLOCK
+	startX := block % blocks * INVISIBLE_WATERMARK_BLOCK
+	startY := block / blocks * INVISIBLE_WATERMARK_BLOCK
+	for y := range INVISIBLE_WATERMARK_BLOCK {
+		for x := range INVISIBLE_WATERMARK_BLOCK {
+			fn((startY+y)*INVISIBLE_WATERMARK_SIZE+startX+x, x, y)
+		}
+	}
+}
+
+// 生成正交DCT的基函数.
+func newDCTBasis() [INVISIBLE_WATERMARK_BLOCK][INVISIBLE_WATERMARK_BLOCK]float64 {
+	var basis [INVISIBLE_WATERMARK_BLOCK][INVISIBLE_WATERMARK_BLOCK]float64
+	for u := range INVISIBLE_WATERMARK_BLOCK {
+		scale := math.Sqrt(2.0 / INVISIBLE_WATERMARK_BLOCK)
+		if u == 0 {
+			scale = math.Sqrt(1.0 / INVISIBLE_WATERMARK_BLOCK)
+		}
+		for x := range INVISIBLE_WATERMARK_BLOCK {
+			basis[u][x] = scale * math.Cos(float64((2*x+1)*u)*math.Pi/(2*INVISIBLE_WATERMARK_BLOCK))
+		}
+	}
+
+	return basis
+}
+
+// 计算区域的亮度并按照面积平均缩放到固定尺寸,先缩放每一行,再缩放每一列.
+func getCanonicalLuminance(img image.Image, rect image.Rectangle) []float64 {
+	size := INVISIBLE_WATERMARK_SIZE
+	columns := getAreaWeights(rect.Dx())
+	rows := make([]float64, rect.Dy()*size)
+	ParallelRows(rect.Dy(), func(startRow, endRow int) {
+		line := make([]float64, rect.Dx())
+		for y := startRow; y < endRow; y++ {
+			for x := range line {
+				line[x] = luminanceAt(img, rect.Min.X+x, rect.Min.Y+y)
+			}
+			for i, weights := range columns {
+				for _, weight := range weights {
+					rows[y*size+i] += line[weight.index] * weight.weight
+				}
+			}
+		}
+	})
+	luminance := make([]float64, size*size)
+	for i, weights := range getAreaWeights(rect.Dy()) {
+		for _, weight := range weights {
+			for x := range size {
+				luminance[i*size+x] += rows[weight.index*size+x] * weight.weight
+			}
+		}
+	}
+
+	return luminance
+}
+
+// 计算长度为length的区间缩放到固定尺寸时,每个位置覆盖的原始位置与面积权重.
+func getAreaWeights(length int) [][]areaWeight {
+	scale := float64(length) / INVISIBLE_WATERMARK_SIZE
+	weights := make([][]areaWeight, INVISIBLE_WATERMARK_SIZE)
+	for i := range weights {
+		start := float64(i) * scale
+		end := start + scale
+		for index := int(start); index < length && float64(index) < end; index++ {
+			overlap := min(end, float64(index+1)) - max(start, float64(index))
+			weights[i] = append(weights[i], areaWeight{index: index, weight: overlap / scale})
+		}
+	}
+
+	return weights
+}
+
+// 获取像素的亮度,范围0-255,jpeg图片直接使用Y分量.
+func luminanceAt(img image.Image, x, y int) float64 {
+	switch src := img.(type) {
+	case *image.RGBA:
+		i := src.PixOffset(x, y)
+
+		return 0.299*float64(src.Pix[i]) + 0.587*float64(src.Pix[i+1]) + 0.114*float64(src.Pix[i+2])
+	case *image.YCbCr:
+		return float64(src.Y[src.YOffset(x, y)])
+	}
+	r, g, b, _ := img.At(x, y).RGBA()
+
+	return (0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)) / 0x101
+}
+
+// 将固定尺寸的亮度变化双线性插值到区域中,并累加到每个像素的颜色分量上.
+func applyCanonicalDifference(img *image.RGBA, rect image.Rectangle, diff []float64) {
+	size := INVISIBLE_WATERMARK_SIZE
+	columns := make([]canonicalSample, rect.Dx())
+	for x := range columns {
+		columns[x] = newCanonicalSample(x, rect.Dx())
+	}
+	ParallelRows(rect.Dy(), func(startRow, endRow int) {
+		for y := rect.Min.Y + startRow; y < rect.Min.Y+endRow; y++ {
+			row := newCanonicalSample(y-rect.Min.Y, rect.Dy())
+			for x := rect.Min.X; x < rect.Max.X; x++ {
+				column := columns[x-rect.Min.X]
+				top := diff[row.start*size+column.start]*(1-column.weight) + diff[row.start*size+column.end]*column.weight
+				bottom := diff[row.end*size+column.start]*(1-column.weight) + diff[row.end*size+column.end]*column.weight
+				addPixelLuminance(img, x, y, top*(1-row.weight)+bottom*row.weight)
+			}
+		}
+	})
+}
+
+// 像素的颜色分量同时增加change,颜色为预乘透明度的格式,变化量按照透明度缩放.
+func addPixelLuminance(img *image.RGBA, x, y int, change float64) {
+	i := img.PixOffset(x, y)
+	alpha := int(img.Pix[i+3])
+	// 颜色分量都是整数,只需要对变化量取整
+	delta := int(math.Round(change * float64(alpha) / 0xff))
+	if delta == 0 {
+		return
+	}
+	for c := range 3 {
+		img.Pix[i+c] = uint8(max(0, min(alpha, int(img.Pix[i+c])+delta)))
+	}
+}
+
+// 计算区域中的位置在固定尺寸中对应的采样位置.
+func newCanonicalSample(pos, length int) canonicalSample {
+	last := float64(INVISIBLE_WATERMARK_SIZE - 1)
+	f := max(0, min(last, (float64(pos)+0.5)*INVISIBLE_WATERMARK_SIZE/float64(length)-0.5))
+	start := int(f)
+
+	return canonicalSample{start: start, end: min(start+1, INVISIBLE_WATERMARK_SIZE-1), weight: f - float64(start)}
+}
@@ -0,0 +1,193 @@
+package pkg
+
+import "image"
+
+const (
+	// 平铺水印层,按照原始像素在照片区域中重复平铺,裁剪的位置不准确但是没有缩放时读取.
+	INVISIBLE_WATERMARK_LAYER_TILE = "tile"
+
+	// 平铺水印每一块的边长,单位为分块,每一块都包含完整的水印数据.
+	INVISIBLE_WATERMARK_TILE_BLOCKS = 16
+
+	// 读取平铺水印时使用的最大边长,图片超出时只读取中间的区域.
+	INVISIBLE_WATERMARK_TILE_SEARCH = 1024
+)
+
+// 平铺水印的嵌入层,分块直接对应原始像素,不随图片尺寸缩放.
+var invisibleWatermarkTileLayer = invisibleWatermarkLayer{
+	name:         INVISIBLE_WATERMARK_LAYER_TILE,
+	coefficients: [][2]int{{1, 2}, {2, 1}},
+	seed:         0x94d049bb133111eb,
+}
+
+// 从区域左上角开始按照原始像素平铺嵌入水印,区域边缘不完整的分块不嵌入.
+func (layer *invisibleWatermarkLayer) embedTiles(img *image.RGBA, rect image.Rectangle, bits []bool) {
+	tileBits := layer.getTileBits(len(bits))
+	blocksX := rect.Dx() / INVISIBLE_WATERMARK_BLOCK
+	blocksY := rect.Dy() / INVISIBLE_WATERMARK_BLOCK
+	for range INVISIBLE_WATERMARK_PASSES {
+		// 不同行的分块之间没有重叠的像素,可以按行并行处理
+		ParallelRows(blocksY, func(startRow, endRow int) {
+			for by := startRow; by < endRow; by++ {
+				for bx := range blocksX {
+					origin := rect.Min.Add(image.Pt(bx, by).Mul(INVISIBLE_WATERMARK_BLOCK))
+					layer.embedBlock(img, origin, bits[tileBits[getTileIndex(bx, by)]])
+				}
+			}
+		})
+	}
+}
+
+// 在原始像素的分块中按照比特位量化DCT系数.
+func (layer *invisibleWatermarkLayer) embedBlock(img *image.RGBA, origin image.Point, bit bool) {
+	var block, diff [INVISIBLE_WATERMARK_BLOCK * INVISIBLE_WATERMARK_BLOCK]float64
+	for y := range INVISIBLE_WATERMARK_BLOCK {
+		for x := range INVISIBLE_WATERMARK_BLOCK {
+			block[y*INVISIBLE_WATERMARK_BLOCK+x] = luminanceAt(img, origin.X+x, origin.Y+y)
+		}
+	}
+	for _, coefficient := range layer.coefficients {
+		change := getQuantizedChange(getBlockCoefficient(block[:], coefficient), bit)
+		for i := range diff {
+			diff[i] += change * getBlockBasis(i, coefficient)
+		}
+	}
+	for i, change := range diff {
+		addPixelLuminance(img, origin.X+i%INVISIBLE_WATERMARK_BLOCK, origin.Y+i/INVISIBLE_WATERMARK_BLOCK, change)
+	}
+}
+
+// 读取平铺水印,裁剪之后分块与平铺块的起始位置都未知,依次尝试分块在像素中的全部对齐位置,选择一致比例最高的结果.
+func (layer *invisibleWatermarkLayer) extractTiles(img image.Image) (InvisibleWatermark, bool) {
+	rect := getTileSearchRect(img.Bounds())
+	luminance := make([]float64, rect.Dx()*rect.Dy())
+	ParallelRows(rect.Dy(), func(startRow, endRow int) {
+		for y := startRow; y < endRow; y++ {
+			for x := range rect.Dx() {
+				luminance[y*rect.Dx()+x] = luminanceAt(img, rect.Min.X+x, rect.Min.Y+y)
+			}
+		}
+	})
+	tileBits := layer.getTileBits(INVISIBLE_WATERMARK_BYTES * 8)
+	alignments := INVISIBLE_WATERMARK_BLOCK * INVISIBLE_WATERMARK_BLOCK
+	marks := make([]InvisibleWatermark, alignments)
+	found := make([]bool, alignments)
+	ParallelRows(alignments, func(start, end int) {
+		for i := start; i < end; i++ {
+			offset := image.Pt(i%INVISIBLE_WATERMARK_BLOCK, i/INVISIBLE_WATERMARK_BLOCK)
+			votes := layer.foldTileVotes(luminance, rect.Size(), offset)
+			marks[i], found[i] = layer.decodeTileVotes(votes, tileBits)
+		}
+	})
+	best := -1
+	for i := range marks {
+		if found[i] && (best < 0 || marks[i].Agreement > marks[best].Agreement) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return InvisibleWatermark{}, false
+	}
+
+	return marks[best], true
+}
+
+// 按照分块的对齐位置计算全部分块的投票,并按照在平铺块中的位置累加,同一个位置的分块对应同一个比特位.
+func (layer *invisibleWatermarkLayer) foldTileVotes(luminance []float64, size, offset image.Point) []float64 {
+	count := len(layer.coefficients)
+	votes := make([]float64, INVISIBLE_WATERMARK_TILE_BLOCKS*INVISIBLE_WATERMARK_TILE_BLOCKS*count)
+	block := make([]float64, INVISIBLE_WATERMARK_BLOCK*INVISIBLE_WATERMARK_BLOCK)
+	for by := 0; offset.Y+(by+1)*INVISIBLE_WATERMARK_BLOCK <= size.Y; by++ {
+		for bx := 0; offset.X+(bx+1)*INVISIBLE_WATERMARK_BLOCK <= size.X; bx++ {
+			start := (offset.Y+by*INVISIBLE_WATERMARK_BLOCK)*size.X + offset.X + bx*INVISIBLE_WATERMARK_BLOCK
+			for y := range INVISIBLE_WATERMARK_BLOCK {
+				row := start + y*size.X
+				copy(block[y*INVISIBLE_WATERMARK_BLOCK:], luminance[row:row+INVISIBLE_WATERMARK_BLOCK])
+			}
+			cell := getTileIndex(bx, by)
+			for i, coefficient := range layer.coefficients {
+				votes[cell*count+i] += getQuantizedVote(getBlockCoefficient(block, coefficient))
+			}
+		}
+	}
+
+	return votes
+}
+
+// 依次尝试平铺块中的全部起始位置解码累加之后的投票,返回一致比例最高的结果.
+func (layer *invisibleWatermarkLayer) decodeTileVotes(votes []float64, tileBits []int) (InvisibleWatermark, bool) {
+	count := len(layer.coefficients)
+	cells := INVISIBLE_WATERMARK_TILE_BLOCKS * INVISIBLE_WATERMARK_TILE_BLOCKS
+	sums := make([]float64, INVISIBLE_WATERMARK_BYTES*8)
+	bits := make([]bool, len(sums))
+	var best InvisibleWatermark
+	found := false
+	for shift := range cells {
+		clear(sums)
+		for cell := range cells {
+			for i := range count {
+				sums[tileBits[getShiftedTileIndex(cell, shift)]] += votes[cell*count+i]
+			}
+		}
+		for i, sum := range sums {
+			bits[i] = sum > 0
+		}
+		mark, ok := decodeInvisibleWatermark(bits)
+		if !ok {
+			continue
+		}
+		agreement := 0
+		for i, vote := range votes {
+			if (vote > 0) == bits[tileBits[getShiftedTileIndex(i/count, shift)]] {
+				agreement++
+			}
+		}
+		mark.Layer = layer.name
+		mark.Agreement = float64(agreement) / float64(len(votes))
+		if !found || mark.Agreement > best.Agreement {
+			best, found = mark, true
+		}
+	}
+
+	return best, found
+}
+
+// 获取平铺块中每个分块对应的比特位.
+func (layer *invisibleWatermarkLayer) getTileBits(bitCount int) []int {
+	return layer.getBlockBits(INVISIBLE_WATERMARK_TILE_BLOCKS*INVISIBLE_WATERMARK_TILE_BLOCKS, bitCount)
+}
+
+// 计算分块中指定位置的DCT系数,block为按行排列的分块亮度.
+func getBlockCoefficient(block []float64, coefficient [2]int) float64 {
+	var value float64
+	for i, luminance := range block {
+		value += luminance * getBlockBasis(i, coefficient)
+	}
+
+	return value
+}
+
+// 获取DCT系数在分块中第i个像素的基函数值.
+func getBlockBasis(i int, coefficient [2]int) float64 {
+	return dctBasis[coefficient[0]][i/INVISIBLE_WATERMARK_BLOCK] * dctBasis[coefficient[1]][i%INVISIBLE_WATERMARK_BLOCK]
+}
+
+// 获取分块在平铺块中的位置.
+func getTileIndex(bx, by int) int {
+	return by%INVISIBLE_WATERMARK_TILE_BLOCKS*INVISIBLE_WATERMARK_TILE_BLOCKS + bx%INVISIBLE_WATERMARK_TILE_BLOCKS
+}
+
+// 平铺块中的位置按照起始位置偏移之后的位置,shift与cell使用相同的编号方式.
+func getShiftedTileIndex(cell, shift int) int {
+	tile := INVISIBLE_WATERMARK_TILE_BLOCKS
+
+	return getTileIndex(cell%tile+shift%tile, cell/tile+shift/tile)
+}
+
+// 获取读取平铺水印的区域,图片较大时只读取中间的区域.
+func getTileSearchRect(bounds image.Rectangle) image.Rectangle {
+	size := image.Pt(min(bounds.Dx(), INVISIBLE_WATERMARK_TILE_SEARCH), min(bounds.Dy(), INVISIBLE_WATERMARK_TILE_SEARCH))
+	start := bounds.Min.Add(bounds.Size().Sub(size).Div(2))
+
+	return image.Rectangle{Min: start, Max: start.Add(size)}
+}
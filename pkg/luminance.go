@@ -0,0 +1,79 @@
+package pkg
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+const (
+	// 计算图片亮度时每个方向上最多采样的像素数量.
+	LUMINANCE_SAMPLE_SIZE = 256
+
+	// 亮度低于该值时认为是深色.
+	DARK_LUMINANCE = 0.179
+)
+
+// 计算颜色的相对亮度(WCAG),范围0-1,忽略透明度.
+func ColorLuminance(c color.Color) float64 {
+	r, g, b, a := c.RGBA()
+	if a == 0 {
+		return 0
+	}
+	// 还原预乘透明度之后的颜色
+	red := linearizeSRGB(float64(r) / float64(a))
+	green := linearizeSRGB(float64(g) / float64(a))
+	blue := linearizeSRGB(float64(b) / float64(a))
+
+	return 0.2126*red + 0.7152*green + 0.0722*blue
+}
+
+// 计算图片指定区域按照透明度加权的平均相对亮度,区域完全透明时返回false.
+func ImageLuminance(img image.Image, rect image.Rectangle) (float64, bool) {
+	rect = rect.Intersect(img.Bounds())
+	if rect.Empty() {
+		return 0, false
+	}
+	step := max(1, max(rect.Dx(), rect.Dy())/LUMINANCE_SAMPLE_SIZE)
+	var sum, weight float64
+	for y := rect.Min.Y; y < rect.Max.Y; y += step {
+		for x := rect.Min.X; x < rect.Max.X; x += step {
+			c := img.At(x, y)
+			_, _, _, a := c.RGBA()
+			if a == 0 {
+				continue
+			}
+			alpha := float64(a) / 0xffff
+			sum += ColorLuminance(c) * alpha
+			weight += alpha
+		}
+	}
+	if weight == 0 {
+		return 0, false
+	}
+
+	return sum / weight, true
+}
+
+// 计算两个相对亮度之间的对比度(WCAG),范围1-21.
+func ContrastRatio(l1, l2 float64) float64 {
+	if l1 < l2 {
+		l1, l2 = l2, l1
+	}
+
+	return (l1 + 0.05) / (l2 + 0.05)
+}
+
+// 检查相对亮度是否是深色,深色背景上白色的对比度更高.
+func IsDarkLuminance(l float64) bool {
+	return l < DARK_LUMINANCE
+}
+
+// sRGB颜色分量转换为线性值.
+func linearizeSRGB(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
@@ -12,6 +12,9 @@ const (
 
 	// windows系统.
 	Window = "windows"
+
+	// linux系统.
+	Linux = "linux"
 )
 
 // 检查当前运行环境是否为window.
@@ -19,6 +22,11 @@ func IsWindows() bool {
 	return runtime.GOOS == Window
 }
 
+// 检查当前运行环境是否为linux.
+func IsLinux() bool {
+	return runtime.GOOS == Linux
+}
+
 // 获取指定文件夹下面的全部文件(不支持获取文件夹中下级文件夹中的文件).
 func GetDirFiles(directory string) ([]string, EError) {
 	list := make([]string, 0, 100)
@@ -0,0 +1,279 @@
+package pkg
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sort"
+
+	"github.com/disintegration/imaging"
+)
+
+const (
+	// 提取调色板之前照片缩小到的长边尺寸.
+	PALETTE_SAMPLE_SIZE = 96
+
+	// 调色板默认的颜色数量.
+	PALETTE_COLOR_COUNT = 5
+
+	// k-means的最大迭代次数.
+	PALETTE_MAX_ITERATIONS = 12
+)
+
+type (
+	// 照片的调色板,颜色按照占比从高到低排序.
+	Palette struct {
+		Colors  []PaletteColor
+		Average color.RGBA
+	}
+
+	// 调色板中的颜色与占比.
+	PaletteColor struct {
+		Color  color.RGBA
+		Weight float64
+	}
+
+	// k-means计算过程中的颜色.
+	paletteVector [3]float64
+)
+
+// 使用k-means从缩小之后的照片中提取调色板,初始中心按照最远距离选取,同一张照片的结果保持一致.
+func NewPalette(img image.Image, count int) Palette {
+	pixels := getPalettePixels(img)
+	if len(pixels) == 0 {
+		return Palette{}
+	}
+	count = max(1, min(count, len(pixels)))
+	centers := initPaletteCenters(pixels, count)
+	labels := make([]int, len(pixels))
+	for range PALETTE_MAX_ITERATIONS {
+		changed := false
+		for i, p := range pixels {
+			nearest := nearestPaletteCenter(centers, p)
+			if nearest != labels[i] {
+				labels[i] = nearest
+				changed = true
+			}
+		}
+		centers = updatePaletteCenters(pixels, labels, centers)
+		if !changed {
+			break
+		}
+	}
+
+	return newPaletteFromLabels(pixels, labels, centers)
+}
+
+// 主色,调色板中占比最高的颜色.
+func (p Palette) Dominant() color.RGBA {
+	if len(p.Colors) == 0 {
+		return p.Average
+	}
+
+	return p.Colors[0].Color
+}
+
+// 主色的补色,色相旋转180度,饱和度与亮度保持不变.
+func (p Palette) Complement() color.RGBA {
+	h, s, l := rgbToHSL(p.Dominant())
+
+	return hslToRGB(math.Mod(h+0.5, 1), s, l)
+}
+
+// 获取缩小之后照片中不透明的像素.
+func getPalettePixels(img image.Image) []paletteVector {
+	bounds := img.Bounds()
+	if bounds.Empty() {
+		return nil
+	}
+	small := imaging.Fit(img, PALETTE_SAMPLE_SIZE, PALETTE_SAMPLE_SIZE, imaging.Box)
+	pixels := make([]paletteVector, 0, len(small.Pix)/4)
+	for i := 0; i+3 < len(small.Pix); i += 4 {
+		if small.Pix[i+3] == 0 {
+			continue
+		}
+		pixels = append(pixels, paletteVector{float64(small.Pix[i]), float64(small.Pix[i+1]), float64(small.Pix[i+2])})
+	}
+
+	return pixels
+}
+
+// 初始中心:第一个中心为平均色,之后依次选取距离已有中心最远的像素.
+func initPaletteCenters(pixels []paletteVector, count int) []paletteVector {
+	centers := make([]paletteVector, 0, count)
+	centers = append(centers, averagePaletteVector(pixels))
+	distances := make([]float64, len(pixels))
+	for i := range distances {
+		distances[i] = math.MaxFloat64
+	}
+	for len(centers) < count {
+		last := centers[len(centers)-1]
+		farthest := 0
+		for i, p := range pixels {
+			distances[i] = min(distances[i], p.distance(last))
+			if distances[i] > distances[farthest] {
+				farthest = i
+			}
+		}
+		centers = append(centers, pixels[farthest])
+	}
+
+	return centers
+}
+
+// 重新计算每个分组的中心,没有像素的分组保持原中心.
+func updatePaletteCenters(pixels []paletteVector, labels []int, centers []paletteVector) []paletteVector {
+	sums := make([]paletteVector, len(centers))
+	counts := make([]int, len(centers))
+	for i, p := range pixels {
+		for c := range 3 {
+			sums[labels[i]][c] += p[c]
+		}
+		counts[labels[i]]++
+	}
+	for i := range centers {
+		if counts[i] == 0 {
+			continue
+		}
+		for c := range 3 {
+			centers[i][c] = sums[i][c] / float64(counts[i])
+		}
+	}
+
+	return centers
+}
+
+// 按照分组结果生成调色板.
+func newPaletteFromLabels(pixels []paletteVector, labels []int, centers []paletteVector) Palette {
+	counts := make([]int, len(centers))
+	for _, label := range labels {
+		counts[label]++
+	}
+	colors := make([]PaletteColor, 0, len(centers))
+	for i, center := range centers {
+		if counts[i] == 0 {
+			continue
+		}
+		colors = append(colors, PaletteColor{
+			Color:  center.toRGBA(),
+			Weight: float64(counts[i]) / float64(len(pixels)),
+		})
+	}
+	sort.SliceStable(colors, func(i, j int) bool {
+		return colors[i].Weight > colors[j].Weight
+	})
+
+	return Palette{
+		Colors:  colors,
+		Average: averagePaletteVector(pixels).toRGBA(),
+	}
+}
+
+// 距离最近的中心.
+func nearestPaletteCenter(centers []paletteVector, p paletteVector) int {
+	nearest := 0
+	for i := range centers {
+		if p.distance(centers[i]) < p.distance(centers[nearest]) {
+			nearest = i
+		}
+	}
+
+	return nearest
+}
+
+// 所有像素的平均色.
+func averagePaletteVector(pixels []paletteVector) paletteVector {
+	var sum paletteVector
+	for _, p := range pixels {
+		for c := range 3 {
+			sum[c] += p[c]
+		}
+	}
+	for c := range 3 {
+		sum[c] /= float64(len(pixels))
+	}
+
+	return sum
+}
+
+// 两个颜色之间距离的平方.
+func (v paletteVector) distance(o paletteVector) float64 {
+	dr, dg, db := v[0]-o[0], v[1]-o[1], v[2]-o[2]
+
+	return dr*dr + dg*dg + db*db
+}
+
+// 转换为不透明的颜色.
+func (v paletteVector) toRGBA() color.RGBA {
+	return color.RGBA{R: uint8(math.Round(v[0])), G: uint8(math.Round(v[1])), B: uint8(math.Round(v[2])), A: 255}
+}
+
+// rgb转换为hsl,范围均为0-1.
+func rgbToHSL(c color.RGBA) (float64, float64, float64) {
+	r, g, b := float64(c.R)/255, float64(c.G)/255, float64(c.B)/255
+	maxValue, minValue := max(r, g, b), min(r, g, b)
+	l := (maxValue + minValue) / 2
+	if maxValue == minValue {
+		return 0, 0, l
+	}
+	d := maxValue - minValue
+	s := d / (2 - maxValue - minValue)
+	if l <= 0.5 {
+		s = d / (maxValue + minValue)
+	}
+	var h float64
+	switch maxValue {
+	case r:
+		h = (g - b) / d
+		if g < b {
+			h += 6
+		}
+	case g:
+		h = (b-r)/d + 2
+	default:
+		h = (r-g)/d + 4
+	}
+
+	return h / 6, s, l
+}
+
+// hsl转换为rgb.
+func hslToRGB(h, s, l float64) color.RGBA {
+	if s == 0 {
+		v := uint8(math.Round(l * 255))
+
+		return color.RGBA{R: v, G: v, B: v, A: 255}
+	}
+	q := l + s - l*s
+	if l < 0.5 {
+		q = l * (1 + s)
+	}
+	p := 2*l - q
+
+	return color.RGBA{
+		R: uint8(math.Round(hueToRGB(p, q, h+1.0/3) * 255)),
+		G: uint8(math.Round(hueToRGB(p, q, h) * 255)),
+		B: uint8(math.Round(hueToRGB(p, q, h-1.0/3) * 255)),
+		A: 255,
+	}
+}
+
+// 色相转换为颜色分量.
+func hueToRGB(p, q, t float64) float64 {
+	if t < 0 {
+		t++
+	}
+	if t > 1 {
+		t--
+	}
+	switch {
+	case t < 1.0/6:
+		return p + (q-p)*6*t
+	case t < 0.5:
+		return q
+	case t < 2.0/3:
+		return p + (q-p)*(2.0/3-t)*6
+	default:
+		return p
+	}
+}
@@ -2,6 +2,7 @@ package ui
 
 import (
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/wailsapp/wails/v2/pkg/runtime"
@@ -10,6 +11,20 @@ import (
 	"WaterMark/pkg"
 )
 
+// 图片选择对话框中的文件类型,后缀名同时支持大小写.
+func getImageFileFilter(extra ...string) runtime.FileFilter {
+	patterns := make([]string, 0, len(pkg.SupportImageExts)*2+len(extra))
+	for _, ext := range append(slices.Clone(pkg.SupportImageExts), extra...) {
+		patterns = append(patterns, "*"+ext, "*"+strings.ToUpper(ext))
+	}
+	pattern := strings.Join(patterns, ";")
+
+	return runtime.FileFilter{
+		DisplayName: "Images (" + pattern + ")",
+		Pattern:     pattern,
+	}
+}
+
 // SelectImageFile
 //
 // 选择单个图片文件,不支持选择raw格式图片
@@ -22,12 +37,7 @@ func (a *App) SelectImageFile() string {
 		DefaultDirectory: "",
 		DefaultFilename:  "",
 		Title:            "请选择图片",
-		Filters: []runtime.FileFilter{
-			{
-				DisplayName: "Images (*.jpg;*.JPG;*.jpeg;*.JPEG;)",
-				Pattern:     "*.jpg;*.JPG;*.jpeg;*.JPEG;",
-			},
-		},
+		Filters:          []runtime.FileFilter{getImageFileFilter()},
 	})
 	if err != nil {
 		internal.Log.Error("SelectImageFile error:" + err.Error())
@@ -50,12 +60,7 @@ func (a *App) SelectImageFileSupportRaw() string {
 		DefaultDirectory: "",
 		DefaultFilename:  "",
 		Title:            "请选择图片",
-		Filters: []runtime.FileFilter{
-			{
-				DisplayName: "Images (*.jpg;*.JPG;*.jpeg;*.JPEG;*NEF)",
-				Pattern:     "*.jpg;*.JPG;*.jpeg;*.JPEG;*NEF",
-			},
-		},
+		Filters:          []runtime.FileFilter{getImageFileFilter(pkg.RawImageExts...)},
 	})
 	if err != nil {
 		internal.Log.Error("SelectImageFileSupportRaw error:" + err.Error())
@@ -68,7 +73,7 @@ func (a *App) SelectImageFileSupportRaw() string {
 
 // SelectMultipleImageFile
 //
-// 选择多个图片文件
+// 选择多个图片文件,支持选择raw格式图片
 // 如果已选择,则返回对应的图片地址,多个图片地址中间使用,隔开
 // 如果没有选择,返回空字符串
 //
@@ -78,12 +83,7 @@ func (a *App) SelectMultipleImageFile() string {
 		DefaultDirectory: "",
 		DefaultFilename:  "",
 		Title:            "请选择图片",
-		Filters: []runtime.FileFilter{
-			{
-				DisplayName: "Images (*.jpg;*.JPG;*.jpeg;*.JPEG;)",
-				Pattern:     "*.jpg;*.JPG;*.jpeg;*.JPEG;",
-			},
-		},
+		Filters:          []runtime.FileFilter{getImageFileFilter(pkg.RawImageExts...)},
 	})
 	if err != nil {
 		internal.Log.Error("SelectMultipleImageFile error:" + err.Error())
@@ -177,7 +177,7 @@ func (a *App) ShowExportPhotoTips(savePath string) string {
 
 // GetDirectoryJpgFiles
 //
-// 获取指定路径下的jpg图片文件名称列表
+// 获取指定路径下支持导入的图片文件名称列表
 //
 // @return string.
 func (a *App) GetDirectoryJpgFiles(path string) string {
@@ -190,9 +190,7 @@ func (a *App) GetDirectoryJpgFiles(path string) string {
 	}
 	arr := make([]string, 0)
 	for i := range files {
-		ext := filepath.Ext(files[i])
-		if !strings.Contains(ext, ".jpg") && !strings.Contains(ext, ".JPG") && !strings.Contains(ext, ".jpeg") &&
-			!strings.Contains(ext, ".JPEG") {
+		if !pkg.IsSupportImageFile(files[i]) {
 			continue
 		}
 		arr = append(arr, path+"/"+files[i])
@@ -5,6 +5,7 @@ import (
 
 	"github.com/spf13/viper"
 	"github.com/wailsapp/wails/v2/pkg/options"
+	"github.com/wailsapp/wails/v2/pkg/options/linux"
 	"github.com/wailsapp/wails/v2/pkg/options/mac"
 	"github.com/wailsapp/wails/v2/pkg/options/windows"
 )
@@ -52,6 +53,12 @@ func getAppOptions(app *App, assets embed.FS, icon []byte) *options.App {
 			WindowIsTranslucent:  true,
 			About:                &mac.AboutInfo{Title: getAppTitle(), Message: "", Icon: icon},
 		},
+		Linux: &linux.Options{ // Linux platform specific options
+			Icon:                icon,
+			WindowIsTranslucent: false,
+			WebviewGpuPolicy:    linux.WebviewGpuPolicyOnDemand,
+			ProgramName:         getAppTitle(),
+		},
 	}
 }
 